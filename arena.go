@@ -0,0 +1,50 @@
+package sjson
+
+import "unsafe"
+
+const (
+	arenaValsChunk  = 256
+	arenaBytesChunk = 4096
+)
+
+// arena allocates backing arrays of decoded slices and strings from slabs, which are
+// reused after reset. A new slab is at least twice as large as the previous one, so after
+// a few documents one slab holds a whole document.
+type arena struct {
+	vals  []interface{} // slab of slice elements
+	bytes []byte        // slab of string data
+}
+
+// makeSlice returns a copy of elems allocated from the slab.
+func (a *arena) makeSlice(elems []interface{}) []interface{} {
+	n := len(elems)
+	if cap(a.vals)-len(a.vals) < n {
+		a.vals = make([]interface{}, 0, max(2*cap(a.vals), n, arenaValsChunk))
+	}
+	start := len(a.vals)
+	a.vals = append(a.vals, elems...)
+	return a.vals[start : start+n : start+n]
+}
+
+// allocBytes returns empty slice with capacity n from the slab.
+func (a *arena) allocBytes(n int) []byte {
+	if cap(a.bytes)-len(a.bytes) < n {
+		a.bytes = make([]byte, 0, max(2*cap(a.bytes), n, arenaBytesChunk))
+	}
+	start := len(a.bytes)
+	a.bytes = a.bytes[:start+n]
+	return a.bytes[start : start : start+n]
+}
+
+// copyString returns a copy of str allocated from the slab.
+func (a *arena) copyString(str string) string {
+	buf := append(a.allocBytes(len(str)), str...)
+	return unsafe.String(unsafe.SliceData(buf), len(buf))
+}
+
+// reset makes the whole last slabs available again.
+func (a *arena) reset() {
+	clear(a.vals)
+	a.vals = a.vals[:0]
+	a.bytes = a.bytes[:0]
+}
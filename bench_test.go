@@ -3,9 +3,12 @@ package sjson_test
 import (
 	"compress/gzip"
 	"encoding/json"
+	"fmt"
 	"github.com/vovkasm/go-sjson"
 	"io/ioutil"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -116,3 +119,197 @@ func BenchmarkCode__json(b *testing.B) {
 	}
 	b.SetBytes(int64(len(codeJSON)))
 }
+
+func BenchmarkLinesParallel_sjson(b *testing.B) {
+	data := strings.Repeat(sample+"\n", 10000)
+	for i := 0; i < b.N; i++ {
+		err := sjson.DecodeLinesParallel(strings.NewReader(data), 0, func(lineNo int, v interface{}, err error) {
+			if err != nil {
+				b.Fatal("Unmmarshal:", err)
+			}
+			result = v
+		})
+		if err != nil {
+			b.Fatal("Read:", err)
+		}
+	}
+	b.SetBytes(int64(len(data)))
+}
+
+func BenchmarkCodeDocument_sjson(b *testing.B) {
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	for i := 0; i < b.N; i++ {
+		doc, err := sjson.ParseDocument(codeJSONStr)
+		if err != nil {
+			b.Fatal("Parse:", err)
+		}
+		tree, _ := doc.Root().Key("tree")
+		kids, _ := tree.Key("kids")
+		kid, _ := kids.Index(0)
+		name, _ := kid.Key("name")
+		result = name.String()
+	}
+	b.SetBytes(int64(len(codeJSON)))
+}
+
+var sampleLongString = strings.Repeat("abcdefghijklmnopqrstuvwxyzабвгд ", 32) + `"`
+
+func BenchmarkFindStringSpecial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		result = sjson.FindStringSpecial(sampleLongString)
+	}
+	b.SetBytes(int64(len(sampleLongString)))
+}
+
+func BenchmarkFindStringSpecialGeneric(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		result = sjson.FindStringSpecialGeneric(sampleLongString)
+	}
+	b.SetBytes(int64(len(sampleLongString)))
+}
+
+var sampleWide = func() string {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := 0; i < 20; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(`{`)
+		for k := 0; k < 16; k++ {
+			fmt.Fprintf(&b, `"key%d":[1,2,3,4,5,6,7,8,9,10,11,12],`, k)
+		}
+		b.WriteString(`"id":1}`)
+	}
+	b.WriteString("]")
+	return b.String()
+}()
+
+func BenchmarkWide_sjson(b *testing.B) { benchSimple(b, sampleWide) }
+
+func BenchmarkWideAdaptive_sjson(b *testing.B) {
+	d := sjson.NewDecoder(sjson.DecodeOptions{AdaptivePresizing: true})
+	for i := 0; i < b.N; i++ {
+		r, err := d.Decode(sampleWide)
+		if err != nil {
+			b.Fatal("Unmmarshal:", err)
+		}
+		result = r
+	}
+	b.SetBytes(int64(len(sampleWide)))
+}
+
+func BenchmarkCodeAdaptive_sjson(b *testing.B) {
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	d := sjson.NewDecoder(sjson.DecodeOptions{AdaptivePresizing: true})
+	for i := 0; i < b.N; i++ {
+		r, err := d.Decode(codeJSONStr)
+		if err != nil {
+			b.Fatal("Unmmarshal:", err)
+		}
+		result = r
+	}
+	b.SetBytes(int64(len(codeJSON)))
+}
+
+func BenchmarkCodeInternKeys_sjson(b *testing.B) {
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	d := sjson.NewDecoder(sjson.DecodeOptions{InternKeys: 256})
+	for i := 0; i < b.N; i++ {
+		r, err := d.Decode(codeJSONStr)
+		if err != nil {
+			b.Fatal("Unmmarshal:", err)
+		}
+		result = r
+	}
+	b.SetBytes(int64(len(codeJSON)))
+}
+
+func BenchmarkCodeArena_sjson(b *testing.B) {
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	d := sjson.NewDecoder(sjson.DecodeOptions{Arena: true})
+	for i := 0; i < b.N; i++ {
+		r, err := d.Decode(codeJSONStr)
+		if err != nil {
+			b.Fatal("Unmmarshal:", err)
+		}
+		result = r
+		d.Reset()
+	}
+	b.SetBytes(int64(len(codeJSON)))
+}
+
+// sampleFlags is array-heavy input, its values are boxed without allocations, so slices
+// are almost all allocations made by Decode.
+var sampleFlags = func() string {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(`[true,false,null,[false,true]]`)
+	}
+	b.WriteString("]")
+	return b.String()
+}()
+
+func BenchmarkFlagsDecoder_sjson(b *testing.B) {
+	d := sjson.NewDecoder(sjson.DecodeOptions{})
+	for i := 0; i < b.N; i++ {
+		r, err := d.Decode(sampleFlags)
+		if err != nil {
+			b.Fatal("Unmmarshal:", err)
+		}
+		result = r
+	}
+	b.SetBytes(int64(len(sampleFlags)))
+}
+
+func BenchmarkFlagsArena_sjson(b *testing.B) {
+	d := sjson.NewDecoder(sjson.DecodeOptions{Arena: true})
+	for i := 0; i < b.N; i++ {
+		r, err := d.Decode(sampleFlags)
+		if err != nil {
+			b.Fatal("Unmmarshal:", err)
+		}
+		result = r
+		d.Reset()
+	}
+	b.SetBytes(int64(len(sampleFlags)))
+}
+
+var decoderPool = sync.Pool{New: func() interface{} {
+	return sjson.NewDecoder(sjson.DecodeOptions{})
+}}
+
+func BenchmarkSamplePool_sjson(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			d := decoderPool.Get().(*sjson.Decoder)
+			_, err := d.Decode(sample)
+			if err != nil {
+				b.Fatal("Unmmarshal:", err)
+			}
+			d.Reset()
+			decoderPool.Put(d)
+		}
+	})
+	b.SetBytes(int64(len(sample)))
+}
@@ -0,0 +1,137 @@
+package sjson
+
+import "encoding/json"
+
+// Builder creates values of decoded JSON Text, so own types may be used instead of
+// the default ones. Values are built bottom-up, elements of arrays and objects are
+// the values returned by Builder, and the default containers are not created.
+// Builder is not called for containers which failed to decode, their values are nil.
+//
+// Slices passed to Builder are not used by decoder after the call, so they may be
+// retained. With Arena option elements of arrays are allocated in the arena, so they
+// may be retained only until Decoder.Reset.
+type Builder interface {
+	// Null returns value of JSON null.
+	Null() interface{}
+	// Bool returns value of JSON boolean.
+	Bool(b bool) interface{}
+	// Number returns value of number, text is its source and f is the number converted
+	// to float64 (0 if NumberMode is NumberJSON).
+	Number(text string, f float64) interface{}
+	// String returns value of string.
+	String(str string) interface{}
+	// Array returns value of array.
+	Array(elems []interface{}) interface{}
+	// Object returns value of object, members are in the source order and have
+	// DuplicateKeys policy applied. Values collected by DuplicateCollect are built
+	// with Array.
+	Object(members []Member) interface{}
+}
+
+// buildScalar returns value created by Builder for val decoded from start.
+func (s *decodeState) buildScalar(start int, val interface{}) interface{} {
+	if s.cur[start] == '{' || s.cur[start] == '[' {
+		// containers are built when complete, see buildArray and buildObject
+		return val
+	}
+	b := s.opts.Builder
+	switch v := val.(type) {
+	case nil:
+		return b.Null()
+	case bool:
+		return b.Bool(v)
+	case float64:
+		return b.Number(s.numberText(start), v)
+	case json.Number:
+		return b.Number(string(v), 0)
+	case string:
+		return b.String(v)
+	}
+	return val
+}
+
+func (s *decodeState) buildArray(arr []interface{}) interface{} {
+	if s.err != nil {
+		return nil
+	}
+	return s.opts.Builder.Array(arr)
+}
+
+// addBuiltMember adds member of the object which members start at base of scratch
+// stack according to DuplicateKeys policy, off is the offset of key.
+func (s *decodeState) addBuiltMember(base int, key string, val interface{}, off int, collected *map[string]bool) {
+	sc := s.scratch
+	members := sc.members[base:]
+	if i := sc.findMember(members, s.depth, key); i >= 0 {
+		members[i].Value = s.duplicateValue(key, members[i].Value, val, off, collected)
+		return
+	}
+	sc.members = append(sc.members, Member{key, val})
+	switch n := len(members) + 1; {
+	case n > objectIndexMin:
+		sc.index[s.depth][key] = n - 1
+	case n == objectIndexMin:
+		sc.indexMembers(sc.members[base:], s.depth)
+	}
+}
+
+// buildObject passes members of the object, which start at base of scratch stack,
+// to Builder and removes them from the stack.
+func (s *decodeState) buildObject(base int, collected map[string]bool) interface{} {
+	sc := s.scratch
+	elems := sc.members[base:]
+	var val interface{}
+	if s.err == nil {
+		members := make([]Member, len(elems))
+		copy(members, elems)
+		for i := range members {
+			if collected[members[i].Key] {
+				members[i].Value = s.opts.Builder.Array(members[i].Value.([]interface{}))
+			}
+		}
+		val = s.opts.Builder.Object(members)
+	}
+	clear(elems)
+	sc.members = sc.members[:base]
+	return val
+}
+
+// findMember returns index of key in members of object at depth, or -1. Objects of
+// objectIndexMin members and more are indexed, the index of every depth is reused
+// by the following objects.
+func (sc *scratch) findMember(members []Member, depth int, key string) int {
+	if len(members) >= objectIndexMin {
+		if i, ok := sc.index[depth][key]; ok {
+			return i
+		}
+		return -1
+	}
+	for i := range members {
+		if members[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (sc *scratch) indexMembers(members []Member, depth int) {
+	for len(sc.index) <= depth {
+		sc.index = append(sc.index, nil)
+	}
+	index := sc.index[depth]
+	if index == nil {
+		index = make(map[string]int, len(members))
+		sc.index[depth] = index
+	}
+	clear(index)
+	for i, m := range members {
+		index[m.Key] = i
+	}
+}
+
+func (s *decodeState) numberText(start int) string {
+	if s.opts.CopyStrings {
+		return s.copyString(s.cur[start:s.off])
+	}
+	return s.cur[start:s.off]
+}
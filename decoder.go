@@ -0,0 +1,169 @@
+package sjson
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// NumberMode defines how JSON numbers are decoded.
+type NumberMode int
+
+const (
+	// NumberFloat64 decodes numbers as float64.
+	NumberFloat64 NumberMode = iota
+	// NumberJSON decodes numbers as json.Number (the text of number), so no precision is lost.
+	NumberJSON
+)
+
+// DecodeOptions holds settings of Decoder. Zero value is a valid default configuration.
+type DecodeOptions struct {
+	// PreallocateObjectElems is the initial capacity of decoded maps, zero means the value
+	// of package PreallocateObjectElems, as Decode uses.
+	PreallocateObjectElems int
+	// NumberMode defines type of decoded numbers.
+	NumberMode NumberMode
+	// LoneSurrogates defines how lone UTF-16 surrogates in \u escapes are decoded,
+	// Decode and other functions without DecodeOptions use SurrogateReplace.
+	LoneSurrogates SurrogatePolicy
+	// DuplicateKeys defines how repeated object keys are decoded, Decode and other
+	// functions without DecodeOptions use DuplicateLast.
+	DuplicateKeys DuplicatePolicy
+	// OrderedObjects makes objects decoded as *Object, which keeps order of members,
+	// instead of map[string]interface{}.
+	OrderedObjects bool
+	// ObjectHook is called for every decoded object, when all its members are decoded,
+	// and the returned value is used instead of the object. So inner objects are passed
+	// to the hook before outer ones. It is not called for *Object of OrderedObjects.
+	// Decoding stops if the hook returns an error, Decoder returns this error.
+	ObjectHook func(map[string]interface{}) (interface{}, error)
+	// ArrayHook is the same as ObjectHook, but for arrays.
+	ArrayHook func([]interface{}) (interface{}, error)
+	// Builder creates decoded values instead of default types. Hooks are not called
+	// if Builder is set.
+	Builder Builder
+	// Strict rejects input which ECMA-404 does not allow, but is accepted by default:
+	// data after the value, missing or trailing commas in objects and \' escape.
+	Strict bool
+	// AdaptivePresizing makes Decoder learn typical sizes of objects by its nesting
+	// path and use them to preallocate decoded maps (slices are always allocated
+	// with exact size by Decoder). It helps when documents of the same shape are
+	// decoded many times.
+	AdaptivePresizing bool
+	// InternKeys is the size of Decoder cache of object keys, 0 disables it.
+	// Decoded objects share key strings from the cache, and keys never refer to the input,
+	// so a decoded tree does not retain the source string through its keys.
+	InternKeys int
+	// CopyStrings makes decoded strings copies of the input. By default strings without
+	// escapes are substrings of JSON Text, so any of them keeps the whole input alive.
+	CopyStrings bool
+	// Arena makes Decoder allocate backing arrays of slices and unescaped or copied strings
+	// from its own slabs. Memory is reused after Reset, so decoded values must not be used
+	// after it. Decoder with arena is not safe for concurrent use.
+	// Maps, Objects and interface values holding numbers, strings and slices are still
+	// allocated by GC, so arena pays off mostly for array-heavy input and with CopyStrings,
+	// a typical object-heavy JSON Text saves only a few percent of allocations.
+	Arena bool
+	// MaxDepth limits nesting of arrays and objects, deeper input is rejected with
+	// *LimitError. Zero means DefaultMaxDepth, negative disables the limit.
+	MaxDepth int
+	// MaxInputSize limits length of JSON Text in bytes, 0 means no limit.
+	MaxInputSize int
+	// MaxStringLen limits length of decoded strings and object keys in bytes, 0 means no limit.
+	MaxStringLen int
+	// MaxArrayLen limits number of array elements, 0 means no limit.
+	MaxArrayLen int
+	// MaxObjectLen limits number of object members, 0 means no limit.
+	MaxObjectLen int
+	// MaxValues limits total number of values (including arrays, objects and its
+	// elements) in JSON Text, 0 means no limit.
+	MaxValues int
+}
+
+// defaultOptions are options of Decode and other functions without DecodeOptions,
+// bytesOptions are the same for DecodeBytes. They are shared, so must not be modified.
+var (
+	defaultOptions DecodeOptions
+	bytesOptions   = DecodeOptions{CopyStrings: true}
+)
+
+// bytesString returns string which shares memory with data.
+func bytesString(data []byte) string {
+	return unsafe.String(unsafe.SliceData(data), len(data))
+}
+
+// Decoder decodes JSON Texts with its own options, so different parts of a program
+// can use different settings. Decoder is safe for concurrent use, unless Arena option is set.
+//
+// Decoder keeps scratch buffers between calls, so it is cheaper to reuse one Decoder
+// (or to keep them in sync.Pool) than to create a new one for every JSON Text.
+type Decoder struct {
+	opts      DecodeOptions
+	bytesOpts DecodeOptions // opts of DecodeBytes
+	shapes    *shapeTable
+	keys      *keyCache
+	arena     *arena
+	scratch   atomic.Pointer[scratch] // nil while it is used by some call
+}
+
+// NewDecoder returns decoder with options.
+func NewDecoder(opts DecodeOptions) *Decoder {
+	d := &Decoder{opts: opts, bytesOpts: opts}
+	d.bytesOpts.CopyStrings = true
+	if opts.AdaptivePresizing {
+		d.shapes = new(shapeTable)
+	}
+	if opts.InternKeys > 0 {
+		d.keys = newKeyCache(opts.InternKeys)
+	}
+	if opts.Arena {
+		d.arena = new(arena)
+	}
+	return d
+}
+
+// acquire returns decoding state with scratch buffers of decoder.
+func (d *Decoder) acquire(json string) decodeState {
+	// concurrent calls take scratch in turn, the others use new one
+	sc := d.scratch.Swap(nil)
+	if sc == nil {
+		sc = new(scratch)
+	}
+	return decodeState{cur: json, opts: &d.opts, shapes: d.shapes, path: rootPath, keys: d.keys, arena: d.arena, scratch: sc}
+}
+
+// release returns scratch buffers of the state to decoder.
+func (d *Decoder) release(s *decodeState) {
+	d.scratch.Store(s.scratch)
+}
+
+// Decode parses JSON Text into interface value in the same way as Decode function does.
+func (d *Decoder) Decode(json string) (interface{}, error) {
+	state := d.acquire(json)
+	val, err := state.decode()
+	d.release(&state)
+	return val, err
+}
+
+// DecodeBytes is the same as Decode, but parses JSON Text from data.
+// Decoded values never refer to data, so the caller may reuse the buffer.
+func (d *Decoder) DecodeBytes(data []byte) (interface{}, error) {
+	state := d.acquire(bytesString(data))
+	state.opts = &d.bytesOpts
+	val, err := state.decode()
+	d.release(&state)
+	return val, err
+}
+
+// Reset prepares Decoder for reuse, e.g. before putting it back into sync.Pool.
+// It drops scratch buffers grown by a huge JSON Text and releases memory of all values
+// decoded with arena, so it will be reused by the next calls. Values decoded with arena
+// before Reset must not be used after it.
+func (d *Decoder) Reset() {
+	if sc := d.scratch.Swap(nil); sc != nil {
+		sc.reset()
+		d.scratch.Store(sc)
+	}
+	if d.arena != nil {
+		d.arena.reset()
+	}
+}
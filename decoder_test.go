@@ -0,0 +1,285 @@
+package sjson_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("decoder", func() {
+	It("should decode numbers as json.Number", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{NumberMode: sjson.NumberJSON})
+		res, err := d.Decode(`[12345678901234567890123, -1.5e400, 0]`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal([]interface{}{json.Number("12345678901234567890123"), json.Number("-1.5e400"), json.Number("0")}))
+
+		_, err = d.Decode(`-e`)
+		ExpectSyntaxErr(`incorrect number`, 1)(err)
+	})
+	It("should use own surrogates policy", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{LoneSurrogates: sjson.SurrogateError})
+		_, err := d.Decode(`"\ud800"`)
+		ExpectSyntaxErr(`lone surrogate`, 1)(err)
+		res, err := sjson.Decode(`"\ud800"`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal("�"))
+	})
+	It("should presize containers from observed shapes", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{AdaptivePresizing: true})
+		in := `{"a":[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20],"b":[1,2]}`
+		for i := 0; i < 2; i++ {
+			res, err := d.Decode(in)
+			Expect(err).To(Succeed())
+			obj := res.(map[string]interface{})
+			Expect(obj["a"]).To(HaveLen(20))
+			Expect(obj["b"]).To(Equal([]interface{}{1.0, 2.0}))
+			if i > 0 {
+				Expect(cap(obj["a"].([]interface{}))).To(Equal(20))
+				Expect(cap(obj["b"].([]interface{}))).To(Equal(2))
+			}
+		}
+	})
+	It("should intern object keys", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{InternKeys: 16})
+		in := `[{"site":1},{"si\u0074e":2}]`
+		res, err := d.Decode(in)
+		Expect(err).To(Succeed())
+		arr := res.([]interface{})
+		var keys []string
+		for _, v := range arr {
+			for k := range v.(map[string]interface{}) {
+				keys = append(keys, k)
+			}
+		}
+		Expect(keys).To(Equal([]string{"site", "site"}))
+		Expect(unsafe.StringData(keys[0])).To(BeIdenticalTo(unsafe.StringData(keys[1])))
+		Expect(unsafe.StringData(keys[0])).NotTo(BeIdenticalTo(unsafe.StringData(in[3:])))
+	})
+	It("should copy strings", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{CopyStrings: true, NumberMode: sjson.NumberJSON})
+		in := `{"key":["value",1.5]}`
+		res, err := d.Decode(in)
+		Expect(err).To(Succeed())
+		arr := res.(map[string]interface{})["key"].([]interface{})
+		Expect(arr).To(Equal([]interface{}{"value", json.Number("1.5")}))
+		Expect(unsafe.StringData(arr[0].(string))).NotTo(BeIdenticalTo(unsafe.StringData(in[9:])))
+		Expect(unsafe.StringData(string(arr[1].(json.Number)))).NotTo(BeIdenticalTo(unsafe.StringData(in[16:])))
+	})
+	It("should decode bytes which are reused after", func() {
+		buf := []byte(`{"key":["value"]}`)
+		res, err := sjson.DecodeBytes(buf)
+		Expect(err).To(Succeed())
+		res2, err := sjson.NewDecoder(sjson.DecodeOptions{}).DecodeBytes(buf)
+		Expect(err).To(Succeed())
+		copy(buf, `{"xxx":["xxxxx"]}`)
+		Expect(res).To(Equal(map[string]interface{}{"key": []interface{}{"value"}}))
+		Expect(res2).To(Equal(res))
+	})
+	It("should allocate from arena", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{Arena: true})
+		in := `{"a":[1,[2,"x\ty"],[]],"b\n":["c", 3]}`
+		expected := map[string]interface{}{
+			"a":   []interface{}{1.0, []interface{}{2.0, "x\ty"}, []interface{}{}},
+			"b\n": []interface{}{"c", 3.0},
+		}
+		res, err := d.Decode(in)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(expected))
+		arr := res.(map[string]interface{})["a"].([]interface{})
+		Expect(cap(arr)).To(Equal(3))
+
+		d.Reset()
+		res, err = d.Decode(in)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(expected))
+		Expect(&res.(map[string]interface{})["a"].([]interface{})[0]).To(BeIdenticalTo(&arr[0]))
+
+		_, err = d.Decode(`[1,[2,3}`)
+		ExpectSyntaxErr(`incomplete array`, 7)(err)
+		res, err = d.Decode(`[[1],2]`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal([]interface{}{[]interface{}{1.0}, 2.0}))
+	})
+	It("should reuse scratch buffers", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{InternKeys: 16})
+		res, err := d.Decode(`{"k\n":["a\nb","c\td",[1,["e\"f"]]]}`)
+		Expect(err).To(Succeed())
+		res2, err := d.Decode(`["x\ny",["zzzzzzz\t"]]`)
+		Expect(err).To(Succeed())
+		d.Reset()
+		Expect(res).To(Equal(map[string]interface{}{"k\n": []interface{}{"a\nb", "c\td", []interface{}{1.0, []interface{}{"e\"f"}}}}))
+		Expect(res2).To(Equal([]interface{}{"x\ny", []interface{}{"zzzzzzz\t"}}))
+	})
+	It("should be safe for concurrent use", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{AdaptivePresizing: true, InternKeys: 16})
+		in := `{"a":["x\ty",[1,2,3]],"b\n":{"c":"d"}}`
+		expected, err := sjson.Decode(in)
+		Expect(err).To(Succeed())
+		var wg sync.WaitGroup
+		results := make([]interface{}, 8)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					results[i], _ = d.Decode(in)
+				}
+			}(i)
+		}
+		wg.Wait()
+		for _, res := range results {
+			Expect(res).To(Equal(expected))
+		}
+	})
+	It("should call hooks bottom-up", func() {
+		var calls []string
+		d := sjson.NewDecoder(sjson.DecodeOptions{
+			ObjectHook: func(obj map[string]interface{}) (interface{}, error) {
+				calls = append(calls, "object")
+				if v, ok := obj["$date"].(string); ok {
+					return time.Parse(time.RFC3339, v)
+				}
+				return obj, nil
+			},
+			ArrayHook: func(arr []interface{}) (interface{}, error) {
+				calls = append(calls, "array")
+				return len(arr), nil
+			},
+		})
+		res, err := d.Decode(`{"at":{"$date":"2020-01-02T03:04:05Z"},"list":[[1,2],[{}]]}`)
+		Expect(err).To(Succeed())
+		Expect(calls).To(Equal([]string{"object", "array", "object", "array", "array", "object"}))
+		Expect(res).To(Equal(map[string]interface{}{
+			"at":   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			"list": 2,
+		}))
+
+		_, err = d.Decode(`[{"$date":"yesterday"}]`)
+		Expect(err).To(BeAssignableToTypeOf(&time.ParseError{}))
+	})
+	It("should build values with Builder", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{Builder: testBuilder{}})
+		res, err := d.Decode(`{"b":[1,2.5],"a":[1,"x",true],"n":null,"s":"str","e":[]}`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(testObject{
+			{"b", []float64{1, 2.5}},
+			{"a", []interface{}{testNumber("1"), "x", true}},
+			{"n", testNull{}},
+			{"s", "str"},
+			{"e", []interface{}{}},
+		}))
+
+		res, err = d.Decode(`null`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(testNull{}))
+
+		res, err = d.Decode(`[{"a":1,"b":[}]`)
+		Expect(err).To(HaveOccurred())
+		Expect(res).To(BeNil())
+	})
+	It("should apply duplicate keys policy with Builder", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{Builder: testBuilder{}, DuplicateKeys: sjson.DuplicateCollect})
+		res, err := d.Decode(`{"a":1,"b":"x","a":2,"a":3}`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(testObject{{"a", []float64{1, 2, 3}}, {"b", "x"}}))
+
+		// large objects are indexed, the index of depth is reused by nested and following objects
+		d = sjson.NewDecoder(sjson.DecodeOptions{Builder: testBuilder{}, DuplicateKeys: sjson.DuplicateFirst})
+		var in strings.Builder
+		var expect testObject
+		in.WriteString(`[`)
+		for o := 0; o < 2; o++ {
+			in.WriteString(`{`)
+			expect = nil
+			for i := 0; i < 12; i++ {
+				fmt.Fprintf(&in, `"k%d":"%d","k%d":{"x":[{"k1":1,"k1":2}]},`, i, o, i)
+				expect = append(expect, [2]interface{}{fmt.Sprintf("k%d", i), fmt.Sprint(o)})
+			}
+			in.WriteString(`"end":"1"},`)
+			expect = append(expect, [2]interface{}{"end", "1"})
+		}
+		res, err = d.Decode(strings.TrimSuffix(in.String(), ",") + `]`)
+		Expect(err).To(Succeed())
+		Expect(res.([]interface{})[1]).To(Equal(expect))
+	})
+	Context("strict", func() {
+		lax := sjson.NewDecoder(sjson.DecodeOptions{})
+		strict := sjson.NewDecoder(sjson.DecodeOptions{Strict: true})
+		table := []struct {
+			In     string
+			Msg    string
+			Offset int
+		}{
+			{`true x`, `unexpected data after value`, 5},
+			{`{"a":1 "b":2}`, `expect ',' between object members`, 7},
+			{`{"a":1,}`, `expect object key after ','`, 7},
+			{`"\'"`, `expect escape sequence`, 3},
+		}
+		for _, t := range table {
+			t := t
+			It("should reject "+t.In, func() {
+				_, err := lax.Decode(t.In)
+				Expect(err).To(Succeed())
+				_, err = strict.Decode(t.In)
+				ExpectSyntaxErr(t.Msg, t.Offset)(err)
+			})
+		}
+		It("should accept correct input", func() {
+			res, err := strict.Decode(` {"a":[1,2,3,4,5,6,7,8,9,10],"b":"\/"} `)
+			Expect(err).To(Succeed())
+			Expect(res).To(Equal(map[string]interface{}{
+				"a": []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0},
+				"b": "/",
+			}))
+		})
+	})
+})
+
+type testNull struct{}
+
+type testNumber string
+
+type testObject [][2]interface{}
+
+// testBuilder keeps numbers as testNumber, except in arrays of numbers, which become []float64.
+type testBuilder struct{}
+
+func (testBuilder) Null() interface{}                         { return testNull{} }
+func (testBuilder) Bool(b bool) interface{}                   { return b }
+func (testBuilder) Number(text string, f float64) interface{} { return testNumber(text) }
+func (testBuilder) String(str string) interface{}             { return str }
+
+func (testBuilder) Array(elems []interface{}) interface{} {
+	if len(elems) == 0 {
+		return elems
+	}
+	nums := make([]float64, len(elems))
+	for i, e := range elems {
+		text, ok := e.(testNumber)
+		if !ok {
+			return elems
+		}
+		f, err := strconv.ParseFloat(string(text), 64)
+		if err != nil {
+			return elems
+		}
+		nums[i] = f
+	}
+	return nums
+}
+
+func (testBuilder) Object(members []sjson.Member) interface{} {
+	obj := make(testObject, len(members))
+	for i, m := range members {
+		obj[i] = [2]interface{}{m.Key, m.Value}
+	}
+	return obj
+}
@@ -0,0 +1,382 @@
+package sjson
+
+import (
+	"strconv"
+)
+
+// Type is a type of JSON value.
+type Type int
+
+const (
+	TypeInvalid Type = iota // zero Element, e.g. not found by Key or Index
+	TypeNull
+	TypeBool
+	TypeNumber
+	TypeString
+	TypeArray
+	TypeObject
+)
+
+var typeNames = [...]string{"Invalid", "Null", "Bool", "Number", "String", "Array", "Object"}
+
+func (t Type) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return "Type(" + strconv.Itoa(int(t)) + ")"
+	}
+	return typeNames[t]
+}
+
+// tapeEntry describes one value (or object key) of document. Arrays and objects are
+// followed by entries of its elements, object members are stored as key and value pairs.
+type tapeEntry struct {
+	typ  Type
+	flag bool    // string contains escapes, or value of bool
+	off  int     // offset of string contents or number
+	end  int     // offset after string contents or number, or tape index after the container
+	n    int     // number of array elements or object members
+	num  float64 // value of number
+}
+
+// Document is a parsed JSON Text, stored as a flat tape of values which refer
+// to the source string. Values are materialized lazily by Element methods, so
+// no maps and slices are allocated for objects and arrays.
+type Document struct {
+	json string
+	tape []tapeEntry
+	opts *DecodeOptions
+}
+
+// ParseDocument parses JSON Text into Document. As Decode, it accepts missing and
+// trailing commas in objects.
+func ParseDocument(json string) (*Document, error) {
+	opts := &defaultOptions
+	b := docBuilder{decodeState: decodeState{cur: json, opts: opts}, tape: make([]tapeEntry, 0, len(json)/8+1)}
+	b.parseValue()
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &Document{json: json, tape: b.tape, opts: opts}, nil
+}
+
+// Root returns the top level value of document.
+func (d *Document) Root() Element {
+	return Element{d, 0}
+}
+
+// Element is a value in Document. The zero Element has TypeInvalid and behaves
+// like an empty value of unknown type, so lookups may be chained without checks.
+type Element struct {
+	doc *Document
+	i   int // index in tape
+}
+
+// invalidEntry is the entry of the zero Element.
+var invalidEntry = tapeEntry{typ: TypeInvalid}
+
+func (e Element) entry() *tapeEntry {
+	if e.doc == nil {
+		return &invalidEntry
+	}
+	return &e.doc.tape[e.i]
+}
+
+// Type returns type of element.
+func (e Element) Type() Type {
+	return e.entry().typ
+}
+
+// Len returns number of array elements or object members, or 0 for other types.
+func (e Element) Len() int {
+	return e.entry().n
+}
+
+// Bool returns value of bool element, or false for other types.
+func (e Element) Bool() bool {
+	t := e.entry()
+	return t.typ == TypeBool && t.flag
+}
+
+// Float returns value of number element, or 0 for other types.
+func (e Element) Float() float64 {
+	return e.entry().num
+}
+
+// String returns value of string element, or "" for other types.
+func (e Element) String() string {
+	t := e.entry()
+	if t.typ != TypeString {
+		return ""
+	}
+	return e.doc.str(t)
+}
+
+// Key returns value of object member with the name.
+func (e Element) Key(name string) (Element, bool) {
+	t := e.entry()
+	if t.typ != TypeObject {
+		return Element{}, false
+	}
+	for i := e.i + 1; i < t.end; i = e.doc.next(i + 1) {
+		k := &e.doc.tape[i]
+		if (!k.flag && e.doc.json[k.off:k.end] == name) || (k.flag && e.doc.str(k) == name) {
+			return Element{e.doc, i + 1}, true
+		}
+	}
+	return Element{}, false
+}
+
+// Index returns array element with the index.
+func (e Element) Index(idx int) (Element, bool) {
+	t := e.entry()
+	if t.typ != TypeArray || idx < 0 || idx >= t.n {
+		return Element{}, false
+	}
+	i := e.i + 1
+	for ; idx > 0; idx-- {
+		i = e.doc.next(i)
+	}
+	return Element{e.doc, i}, true
+}
+
+// Iter returns iterator over array elements or object members.
+func (e Element) Iter() Iter {
+	t := e.entry()
+	it := Iter{doc: e.doc, next: e.i + 1, end: e.i + 1, obj: t.typ == TypeObject}
+	if t.typ == TypeObject || t.typ == TypeArray {
+		it.end = t.end
+	}
+	return it
+}
+
+// Value decodes element in the same way as Decode does.
+func (e Element) Value() interface{} {
+	t := e.entry()
+	switch t.typ {
+	case TypeBool:
+		return t.flag
+	case TypeNumber:
+		return t.num
+	case TypeString:
+		return e.doc.str(t)
+	case TypeArray:
+		arr := make([]interface{}, 0, t.n)
+		for it := e.Iter(); it.Next(); {
+			arr = append(arr, it.Value().Value())
+		}
+		return arr
+	case TypeObject:
+		obj := make(map[string]interface{}, t.n)
+		for it := e.Iter(); it.Next(); {
+			obj[it.Key()] = it.Value().Value()
+		}
+		return obj
+	}
+	return nil
+}
+
+// Iter is an iterator over array elements or object members.
+//
+//	for it := elem.Iter(); it.Next(); {
+//		fmt.Println(it.Key(), it.Value().Type())
+//	}
+type Iter struct {
+	doc  *Document
+	cur  int // index of the current element (or key)
+	next int
+	end  int
+	obj  bool
+}
+
+// Next advances iterator to the next element, it returns false when there are no more elements.
+func (it *Iter) Next() bool {
+	if it.next >= it.end {
+		return false
+	}
+	it.cur = it.next
+	if it.obj {
+		it.next = it.doc.next(it.cur + 1)
+	} else {
+		it.next = it.doc.next(it.cur)
+	}
+	return true
+}
+
+// Key returns key of the current object member, or "" for arrays.
+func (it *Iter) Key() string {
+	if !it.obj {
+		return ""
+	}
+	return it.doc.str(&it.doc.tape[it.cur])
+}
+
+// Value returns the current element.
+func (it *Iter) Value() Element {
+	if it.obj {
+		return Element{it.doc, it.cur + 1}
+	}
+	return Element{it.doc, it.cur}
+}
+
+// next returns tape index of the element after the element at i.
+func (d *Document) next(i int) int {
+	if t := d.tape[i].typ; t == TypeArray || t == TypeObject {
+		return d.tape[i].end
+	}
+	return i + 1
+}
+
+func (d *Document) str(t *tapeEntry) string {
+	if !t.flag {
+		return d.json[t.off:t.end]
+	}
+	state := decodeState{cur: d.json, off: t.off, opts: d.opts}
+	return state.decodeString()
+}
+
+type docBuilder struct {
+	decodeState
+	tape []tapeEntry
+}
+
+func (b *docBuilder) parseValue() {
+	b.skipSpaces()
+	if len(b.cur) <= b.off {
+		b.error(UnexpectedToken, "incorrect syntax - expect value")
+		return
+	}
+	switch b.cur[b.off] {
+	case '"':
+		b.off++
+		b.parseString()
+	case '{', '[':
+		if !b.enter() {
+			return
+		}
+		if b.cur[b.off] == '{' {
+			b.parseObject()
+		} else {
+			b.parseArray()
+		}
+		b.leave()
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		start := b.off
+		f := b.decodeNumber()
+		b.tape = append(b.tape, tapeEntry{typ: TypeNumber, off: start, end: b.off, num: f})
+	default:
+		val := b.decodeValue()
+		if bv, ok := val.(bool); ok {
+			b.tape = append(b.tape, tapeEntry{typ: TypeBool, flag: bv})
+		} else {
+			b.tape = append(b.tape, tapeEntry{typ: TypeNull})
+		}
+	}
+}
+
+// parseString adds string entry, b.off should point after the open quote.
+func (b *docBuilder) parseString() {
+	start := b.off
+	pos := findStringSpecial(b.cur[b.off:])
+	if pos >= 0 && b.cur[b.off+pos] == '"' {
+		b.off += pos + 1
+		b.tape = append(b.tape, tapeEntry{typ: TypeString, off: start, end: b.off - 1})
+		return
+	}
+	// validate escapes, value will be unescaped again on access
+	b.decodeString()
+	b.tape = append(b.tape, tapeEntry{typ: TypeString, flag: true, off: start, end: b.off - 1})
+}
+
+func (b *docBuilder) parseObject() {
+	i := len(b.tape)
+	b.tape = append(b.tape, tapeEntry{typ: TypeObject, off: b.off})
+	b.off++
+
+	n := 0
+	var comma bool
+	for {
+		b.skipSpaces()
+		if len(b.cur) <= b.off {
+			b.error(UnexpectedToken, "incorrect syntax - object")
+			return
+		}
+		switch b.cur[b.off] {
+		case '}':
+			if b.checkObjectComma('}', n, comma) {
+				b.off++
+				b.tape[i].end = len(b.tape)
+				b.tape[i].n = n
+			}
+			return
+		case '"':
+			if !b.checkObjectComma('"', n, comma) {
+				return
+			}
+		default:
+			b.error(UnexpectedToken, "incorrect syntax - expect object key or incomplete object")
+			return
+		}
+		b.off++
+		b.parseString()
+		if b.err != nil {
+			return
+		}
+		b.skipSpaces()
+		if len(b.cur) > b.off && b.cur[b.off] == ':' {
+			b.off++
+		} else {
+			b.error(UnexpectedToken, "incorrect syntax - expect ':' after object key")
+			return
+		}
+		b.parseValue()
+		if b.err != nil {
+			return
+		}
+		n++
+
+		b.skipSpaces()
+		comma = len(b.cur) > b.off && b.cur[b.off] == ','
+		if comma {
+			b.off++
+		}
+	}
+}
+
+func (b *docBuilder) parseArray() {
+	i := len(b.tape)
+	b.tape = append(b.tape, tapeEntry{typ: TypeArray, off: b.off})
+	b.off++
+
+	n := 0
+	b.skipSpaces()
+	if len(b.cur) > b.off && b.cur[b.off] == ']' {
+		b.off++
+		b.tape[i].end = len(b.tape)
+		return
+	}
+
+	for {
+		b.parseValue()
+		if b.err != nil {
+			return
+		}
+		n++
+
+		b.skipSpaces()
+		if len(b.cur) <= b.off {
+			b.error(UnexpectedToken, "incorrect syntax - incomplete array")
+			return
+		}
+		switch b.cur[b.off] {
+		case ',':
+			b.off++
+		case ']':
+			b.off++
+			b.tape[i].end = len(b.tape)
+			b.tape[i].n = n
+			return
+		default:
+			b.error(UnexpectedToken, "incorrect syntax - incomplete array")
+			return
+		}
+	}
+}
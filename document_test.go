@@ -0,0 +1,97 @@
+package sjson_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("document", func() {
+	It("should provide lazy access to elements", func() {
+		doc, err := sjson.ParseDocument(` {"a" : [1, "x\"", true, null], "b":{"c":"d"}, "eA":2.5} `)
+		Expect(err).To(Succeed())
+		root := doc.Root()
+		Expect(root.Type()).To(Equal(sjson.TypeObject))
+		Expect(root.Len()).To(Equal(3))
+
+		a, ok := root.Key("a")
+		Expect(ok).To(BeTrue())
+		Expect(a.Type()).To(Equal(sjson.TypeArray))
+		Expect(a.Len()).To(Equal(4))
+		el, ok := a.Index(1)
+		Expect(ok).To(BeTrue())
+		Expect(el.String()).To(Equal(`x"`))
+		el, _ = a.Index(2)
+		Expect(el.Bool()).To(BeTrue())
+		el, _ = a.Index(3)
+		Expect(el.Type()).To(Equal(sjson.TypeNull))
+		_, ok = a.Index(4)
+		Expect(ok).To(BeFalse())
+
+		c, _ := root.Key("b")
+		c, ok = c.Key("c")
+		Expect(ok).To(BeTrue())
+		Expect(c.String()).To(Equal("d"))
+
+		e, ok := root.Key("eA")
+		Expect(ok).To(BeTrue())
+		Expect(e.Float()).To(Equal(2.5))
+
+		_, ok = root.Key("x")
+		Expect(ok).To(BeFalse())
+
+		var keys []string
+		for it := root.Iter(); it.Next(); {
+			keys = append(keys, it.Key())
+		}
+		Expect(keys).To(Equal([]string{"a", "b", "eA"}))
+	})
+	It("should return safe zero element if not found", func() {
+		doc, err := sjson.ParseDocument(`{"a":[1]}`)
+		Expect(err).To(Succeed())
+		e, ok := doc.Root().Key("missing")
+		Expect(ok).To(BeFalse())
+		Expect(e.Type()).To(Equal(sjson.TypeInvalid))
+		Expect(e.Len()).To(Equal(0))
+		Expect(e.String()).To(Equal(""))
+		Expect(e.Value()).To(BeNil())
+		_, ok = e.Key("a")
+		Expect(ok).To(BeFalse())
+		_, ok = e.Index(0)
+		Expect(ok).To(BeFalse())
+		it := e.Iter()
+		Expect(it.Next()).To(BeFalse())
+
+		a, _ := doc.Root().Key("a")
+		e, ok = a.Index(5)
+		Expect(ok).To(BeFalse())
+		Expect(e.Type()).To(Equal(sjson.TypeInvalid))
+		Expect(sjson.Element{}.Type().String()).To(Equal("Invalid"))
+	})
+	It("should report syntax errors", func() {
+		_, err := sjson.ParseDocument(`[1 2]`)
+		ExpectSyntaxErr(`incomplete array`, 3)(err)
+		_, err = sjson.ParseDocument(`{"a":"\x"}`)
+		ExpectSyntaxErr(`expect escape sequence`, 8)(err)
+		_, err = sjson.ParseDocument(`{"a":1,,}`)
+		ExpectSyntaxErr(`expect object key`, 7)(err)
+	})
+	It("should accept missing and trailing commas in objects as Decode", func() {
+		doc, err := sjson.ParseDocument(`{"a":1 "b":2,}`)
+		Expect(err).To(Succeed())
+		Expect(doc.Root().Len()).To(Equal(2))
+		Expect(doc.Root().Value()).To(Equal(map[string]interface{}{"a": 1.0, "b": 2.0}))
+	})
+	It("should produce values equivalent to Decode", func() {
+		if codeJSON == nil {
+			codeInit()
+		}
+		doc, err := sjson.ParseDocument(codeJSONStr)
+		Expect(err).To(Succeed())
+		enc, err := json.Marshal(doc.Root().Value())
+		Expect(err).To(Succeed())
+		Expect(enc).To(MatchJSON(codeJSONStr))
+	})
+})
@@ -0,0 +1,174 @@
+package sjson
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrorKind classifies syntax errors. It implements error, so kinds may be used as
+// sentinel errors with errors.Is:
+//
+//	if errors.Is(err, sjson.UnexpectedEOF) { ... }
+type ErrorKind int
+
+const (
+	UnexpectedEOF    ErrorKind = iota + 1 // input ends inside of JSON Text
+	UnexpectedToken                       // character which is not allowed at this place
+	InvalidEscape                         // incorrect escape sequence in string
+	InvalidCharacter                      // unescaped control character in string
+	BadNumber                             // incorrect number
+	TrailingData                          // data after JSON Text
+	DuplicateKey                          // repeated object key, see DuplicateError
+
+	DepthLimit  // nesting of arrays and objects exceeds DecodeOptions.MaxDepth, see LimitError
+	SizeLimit   // input is longer than DecodeOptions.MaxInputSize
+	StringLimit // string is longer than DecodeOptions.MaxStringLen
+	ArrayLimit  // array has more elements than DecodeOptions.MaxArrayLen
+	ObjectLimit // object has more members than DecodeOptions.MaxObjectLen
+	ValueLimit  // input has more values than DecodeOptions.MaxValues
+)
+
+var errorKindNames = [...]string{
+	"Invalid",
+	"unexpected end of JSON input",
+	"unexpected token",
+	"invalid escape sequence",
+	"invalid character in string",
+	"bad number",
+	"data after JSON Text",
+	"duplicate object key",
+	"nesting depth",
+	"input size",
+	"string length",
+	"array length",
+	"object members",
+	"number of values",
+}
+
+func (k ErrorKind) Error() string {
+	if k < 0 || int(k) >= len(errorKindNames) {
+		return "ErrorKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return errorKindNames[k]
+}
+
+// excerptWidth is the maximum number of bytes of source line shown on each side of
+// the error position by SyntaxError.Excerpt.
+const excerptWidth = 32
+
+// A SyntaxError is a description of a JSON syntax error.
+type SyntaxError struct {
+	msg    string    // description of error
+	Kind   ErrorKind // class of error
+	Offset int       // current parser position at which the error occurred
+
+	line    int      // 1-based line of Offset
+	column  int      // 1-based column of Offset in runes
+	excerpt string   // source line around Offset, see Excerpt
+	path    []string // reference tokens of the failed value, innermost first
+}
+
+func (e *SyntaxError) Error() string { return e.msg }
+
+// Is reports whether target is the kind of e.
+func (e *SyntaxError) Is(target error) bool {
+	kind, ok := target.(ErrorKind)
+	return ok && kind == e.Kind
+}
+
+// Pointer returns JSON Pointer (RFC 6901) of the value which was decoded when the error
+// occurred, e.g. "/2/3/value", or "" for the root value. It is known only for errors of
+// Decode, Decoder and functions based on them.
+func (e *SyntaxError) Pointer() string {
+	var b strings.Builder
+	for i := len(e.path) - 1; i >= 0; i-- {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(e.path[i]))
+	}
+	return b.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// addPath adds reference token of the value which failed to the path of the error and of
+// errors recovered since mark, it is called while containers unwind after the error.
+func (s *decodeState) addPath(mark int, token string) {
+	if e, ok := s.err.(*SyntaxError); ok {
+		e.path = append(e.path, token)
+	}
+	if s.extra == nil {
+		return
+	}
+	for _, err := range s.extra.errs[mark:] {
+		if e, ok := err.(*SyntaxError); ok {
+			e.path = append(e.path, token)
+		}
+	}
+}
+
+// Line returns 1-based line number of the error position.
+func (e *SyntaxError) Line() int { return e.line }
+
+// Column returns 1-based column of the error position in runes.
+func (e *SyntaxError) Column() int { return e.column }
+
+// Excerpt returns the source line around the error position and the second line with
+// a caret under it, like:
+//
+//	{"a":1 "b":2}
+//	       ^
+func (e *SyntaxError) Excerpt() string { return e.excerpt }
+
+// syntaxError creates error at offset off of the input. Position and excerpt are computed
+// here, so the error does not refer to the input, which the caller may reuse or release.
+func (s *decodeState) syntaxError(kind ErrorKind, msg string, off int) *SyntaxError {
+	pos := min(off, len(s.cur))
+	lp := new(linePos)
+	if s.extra != nil {
+		lp = &s.extra.errPos
+	}
+	lp.advance(s.cur, pos)
+	return &SyntaxError{
+		msg:     msg,
+		Kind:    kind,
+		Offset:  off,
+		line:    lp.line,
+		column:  lp.column,
+		excerpt: excerpt(s.cur, pos),
+	}
+}
+
+// excerpt returns the text of SyntaxError.Excerpt for position pos of src.
+func excerpt(src string, pos int) string {
+	start := max(pos-excerptWidth, 0)
+	if i := strings.LastIndexByte(src[start:pos], '\n'); i >= 0 {
+		start += i + 1
+	} else {
+		for start < pos && !utf8.RuneStart(src[start]) {
+			start++
+		}
+	}
+	end := min(pos+excerptWidth, len(src))
+	if i := strings.IndexByte(src[pos:end], '\n'); i >= 0 {
+		end = pos + i
+	} else {
+		for end > pos && end < len(src) && !utf8.RuneStart(src[end]) {
+			end--
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSuffix(src[start:end], "\r"))
+	b.WriteByte('\n')
+	for _, r := range src[start:pos] {
+		// keep tabs, so the caret is aligned whatever tab width is
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteByte('^')
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package sjson_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("syntax error", func() {
+	decodeErr := func(in string) *sjson.SyntaxError {
+		_, err := sjson.Decode(in)
+		ExpectWithOffset(1, err).To(BeAssignableToTypeOf(&sjson.SyntaxError{}))
+		return err.(*sjson.SyntaxError)
+	}
+	It("should report line and column", func() {
+		err := decodeErr("{\n  \"a\": 1,\n  \"ключ\": tru\n}")
+		Expect(err.Offset).To(Equal(26))
+		Expect(err.Line()).To(Equal(3))
+		Expect(err.Column()).To(Equal(11))
+		Expect(err.Excerpt()).To(Equal("  \"ключ\": tru\n          ^"))
+	})
+	It("should report position on the first line", func() {
+		err := decodeErr(`{"a":1 "b":2`)
+		Expect(err.Line()).To(Equal(1))
+		Expect(err.Column()).To(Equal(13))
+		Expect(err.Excerpt()).To(Equal("{\"a\":1 \"b\":2\n            ^"))
+	})
+	It("should keep tabs and cut long lines in excerpt", func() {
+		err := decodeErr("[\t" + `1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, x, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30]`)
+		Expect(err.Line()).To(Equal(1))
+		Expect(err.Column()).To(Equal(74))
+		Expect(err.Excerpt()).To(Equal("13, 14, 15, 16, 17, 18, 19, 20, x, 21, 22, 23, 24, 25, 26, 27, 2\n                                ^"))
+
+		err = decodeErr("[\t" + `x]`)
+		Expect(err.Excerpt()).To(Equal("[\tx]\n \t^"))
+	})
+	It("should not refer to the input", func() {
+		buf := []byte(`[1, tru]`)
+		_, err := sjson.DecodeBytes(buf)
+		copy(buf, "XXXXXXXX")
+		Expect(err.(*sjson.SyntaxError).Excerpt()).To(Equal("[1, tru]\n    ^"))
+	})
+	It("should count positions of recovered errors", func() {
+		in := "[tru,\n x, \"\\x\",\n\n  {\"ключ\": fals}]"
+		_, errs := sjson.DecodeAll(in)
+		Expect(errs).To(HaveLen(4))
+		for _, err := range errs {
+			e := err.(*sjson.SyntaxError)
+			line, column := sjson.LineColumn(in, e.Offset)
+			Expect([]int{e.Line(), e.Column()}).To(Equal([]int{line, column}))
+		}
+		Expect(errs[3].(*sjson.SyntaxError).Line()).To(Equal(4))
+	})
+	Context("kind", func() {
+		table := []struct {
+			In   string
+			Kind sjson.ErrorKind
+		}{
+			{`[1,2`, sjson.UnexpectedEOF},
+			{`"abc`, sjson.UnexpectedEOF},
+			{`{"a":1]`, sjson.UnexpectedToken},
+			{`[1 2]`, sjson.UnexpectedToken},
+			{`"\x"`, sjson.InvalidEscape},
+			{`"\u12x4"`, sjson.InvalidEscape},
+			{"\"a\tb\"", sjson.InvalidCharacter},
+			{`-x`, sjson.BadNumber},
+			{`[1e400]`, sjson.BadNumber},
+		}
+		for _, t := range table {
+			t := t
+			It("should classify "+t.In, func() {
+				err := decodeErr(t.In)
+				Expect(err.Kind).To(Equal(t.Kind))
+				Expect(errors.Is(err, t.Kind)).To(BeTrue())
+				Expect(errors.Is(fmt.Errorf("wrapped: %w", err), t.Kind)).To(BeTrue())
+				Expect(errors.Is(err, sjson.TrailingData)).To(BeFalse())
+			})
+		}
+		It("should classify trailing data", func() {
+			_, err := sjson.NewDecoder(sjson.DecodeOptions{Strict: true}).Decode(`1 2`)
+			Expect(errors.Is(err, sjson.TrailingData)).To(BeTrue())
+			Expect(sjson.TrailingData.Error()).To(Equal("data after JSON Text"))
+		})
+	})
+	It("should report JSON Pointer of the failed value", func() {
+		Expect(decodeErr(`[1,2,[0,1,2,{"value":tru}]]`).Pointer()).To(Equal("/2/3/value"))
+		Expect(decodeErr(`{"a/b":{"c~d":[1,}}`).Pointer()).To(Equal("/a~1b/c~0d/1"))
+		Expect(decodeErr(`{"a":{"b":1 2}}`).Pointer()).To(Equal("/a"))
+		Expect(decodeErr(`[1 2]`).Pointer()).To(Equal(""))
+		Expect(decodeErr(`{"a":[-1e400]}`).Pointer()).To(Equal("/a/0"))
+
+		_, err := sjson.NewDecoder(sjson.DecodeOptions{Arena: true}).Decode(`[{"a":[0,x]}]`)
+		Expect(err.(*sjson.SyntaxError).Pointer()).To(Equal("/0/a/1"))
+	})
+})
@@ -0,0 +1,14 @@
+package sjson
+
+var (
+	FindStringSpecial        = findStringSpecial
+	FindStringSpecialGeneric = findStringSpecialGeneric
+)
+
+// SetLinesChunkSize changes size of chunks read by DecodeLinesParallel and
+// returns function which restores the previous one.
+func SetLinesChunkSize(size int) (restore func()) {
+	prev := linesChunkSize
+	linesChunkSize = size
+	return func() { linesChunkSize = prev }
+}
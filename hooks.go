@@ -0,0 +1,21 @@
+package sjson
+
+func (s *decodeState) objectHook(obj interface{}) interface{} {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return obj
+	}
+	val, err := s.opts.ObjectHook(m)
+	if err != nil {
+		s.err = err
+	}
+	return val
+}
+
+func (s *decodeState) arrayHook(arr []interface{}) interface{} {
+	val, err := s.opts.ArrayHook(arr)
+	if err != nil {
+		s.err = err
+	}
+	return val
+}
@@ -0,0 +1,140 @@
+package sjson
+
+type incrMode int
+
+const (
+	incrSpace  incrMode = iota // skipping spaces between JSON Texts
+	incrScalar                 // inside top level number or literal
+	incrStr                    // inside string
+	incrStrEsc                 // inside string after backslash
+	incrNest                   // inside array or object
+)
+
+// IncrementalParser extracts JSON Texts from a stream of arbitrary chunks, in the same manner
+// as incr_parse of JSON::XS does. Data is appended with Feed and complete values are
+// taken with Next, so multiple concatenated (possibly whitespace separated) values can be
+// pulled out of one buffer.
+//
+// Parser only tracks the nesting of arrays and objects and the boundaries of strings
+// between calls, values are decoded when its text is complete. Top level numbers and
+// literals are complete only when followed by some other character, so a stream should
+// be terminated with whitespace if it can end with such value.
+type IncrementalParser struct {
+	buf      []byte
+	start    int // start of the current value in buf
+	pos      int // scanning position in buf
+	consumed int // number of bytes discarded from the stream start
+	nest     int // nesting level of arrays and objects
+	mode     incrMode
+	lines    linePos // stream line and column of offset lines.off in buf
+}
+
+// Feed appends data to the parser buffer.
+func (p *IncrementalParser) Feed(data []byte) {
+	if p.start > 0 && p.start >= len(p.buf)/2 {
+		p.lines.advance(bytesString(p.buf), p.start)
+		p.lines.off = 0
+		n := copy(p.buf, p.buf[p.start:])
+		p.buf = p.buf[:n]
+		p.pos -= p.start
+		p.consumed += p.start
+		p.start = 0
+	}
+	p.buf = append(p.buf, data...)
+}
+
+// Next decodes the next complete JSON Text from the buffer. It returns ok == false
+// and nil error if buffer does not contain complete value yet and more data should be fed.
+// On syntax error the text of the failed value is discarded, so the next call continues
+// from the next value. Offset, Line and Column of *SyntaxError are counted from the stream
+// start, Excerpt shows only the text of the failed value.
+func (p *IncrementalParser) Next() (v interface{}, ok bool, err error) {
+	for ; p.pos < len(p.buf); p.pos++ {
+		c := p.buf[p.pos]
+		switch p.mode {
+		case incrSpace:
+			switch c {
+			case '\x20', '\x0A', '\x0D', '\x09':
+				p.start = p.pos + 1
+			case '{', '[':
+				p.mode = incrNest
+				p.nest = 1
+			case '"':
+				p.mode = incrStr
+			default:
+				p.mode = incrScalar
+			}
+		case incrScalar:
+			switch c {
+			case '\x20', '\x0A', '\x0D', '\x09', '{', '}', '[', ']', ',', ':', '"':
+				return p.decode(p.pos)
+			}
+		case incrStr:
+			switch c {
+			case '\\':
+				p.mode = incrStrEsc
+			case '"':
+				if p.nest == 0 {
+					return p.decode(p.pos + 1)
+				}
+				p.mode = incrNest
+			}
+		case incrStrEsc:
+			p.mode = incrStr
+		case incrNest:
+			switch c {
+			case '"':
+				p.mode = incrStr
+			case '{', '[':
+				p.nest++
+			case '}', ']':
+				p.nest--
+				if p.nest == 0 {
+					return p.decode(p.pos + 1)
+				}
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// Reset discards all buffered data.
+func (p *IncrementalParser) Reset() {
+	p.lines.advance(bytesString(p.buf), len(p.buf))
+	p.lines.off = 0
+	p.consumed += len(p.buf)
+	p.buf = p.buf[:0]
+	p.start = 0
+	p.pos = 0
+	p.nest = 0
+	p.mode = incrSpace
+}
+
+func (p *IncrementalParser) decode(end int) (interface{}, bool, error) {
+	state := decodeState{cur: string(p.buf[p.start:end]), opts: &defaultOptions}
+	val := state.decodeValue()
+	if state.err == nil && state.off < len(state.cur) {
+		state.error(TrailingData, "incorrect syntax - unexpected data after value")
+	}
+
+	base := p.consumed + p.start
+	if state.err != nil {
+		p.lines.advance(bytesString(p.buf), p.start)
+	}
+	p.start = end
+	p.pos = end
+	p.nest = 0
+	p.mode = incrSpace
+
+	if state.err != nil {
+		if syntaxErr, ok := state.err.(*SyntaxError); ok {
+			syntaxErr.Offset += base
+			if syntaxErr.line == 1 {
+				syntaxErr.column += p.lines.column - 1
+			}
+			syntaxErr.line += p.lines.line - 1
+		}
+		return nil, false, state.err
+	}
+	return val, true, nil
+}
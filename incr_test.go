@@ -0,0 +1,94 @@
+package sjson_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("incremental parser", func() {
+	stream := `{"a":["]",1]} "x\"}" 12 true[3,{"b":null}]` + "\n"
+	expect := []interface{}{
+		map[string]interface{}{"a": []interface{}{"]", 1.0}},
+		`x"}`,
+		12.0,
+		true,
+		[]interface{}{3.0, map[string]interface{}{"b": nil}},
+	}
+
+	It("should extract concatenated values from one buffer", func() {
+		var p sjson.IncrementalParser
+		p.Feed([]byte(stream))
+		var got []interface{}
+		for {
+			v, ok, err := p.Next()
+			Expect(err).To(Succeed())
+			if !ok {
+				break
+			}
+			got = append(got, v)
+		}
+		Expect(got).To(Equal(expect))
+	})
+	It("should keep partial state across chunks", func() {
+		var p sjson.IncrementalParser
+		var got []interface{}
+		for i := 0; i < len(stream); i++ {
+			p.Feed([]byte{stream[i]})
+			for {
+				v, ok, err := p.Next()
+				Expect(err).To(Succeed())
+				if !ok {
+					break
+				}
+				got = append(got, v)
+			}
+		}
+		Expect(got).To(Equal(expect))
+	})
+	It("should report syntax errors and continue with the next value", func() {
+		var p sjson.IncrementalParser
+		p.Feed([]byte(`[1] [1,,2] 5 `))
+		v, ok, err := p.Next()
+		Expect(err).To(Succeed())
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal([]interface{}{1.0}))
+
+		_, ok, err = p.Next()
+		Expect(ok).To(BeFalse())
+		ExpectSyntaxErr(`unrecognized token`, 7)(err)
+		Expect(err.(*sjson.SyntaxError).Line()).To(Equal(1))
+		Expect(err.(*sjson.SyntaxError).Column()).To(Equal(8))
+
+		v, ok, err = p.Next()
+		Expect(err).To(Succeed())
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal(5.0))
+
+		_, ok, err = p.Next()
+		Expect(err).To(Succeed())
+		Expect(ok).To(BeFalse())
+	})
+	It("should report line and column in the stream", func() {
+		var p sjson.IncrementalParser
+		in := "[1]\n\"ключ\" [2,\n3,x]\n"
+		p.Feed([]byte(in[:16]))
+		for i := 0; i < 2; i++ {
+			_, ok, err := p.Next()
+			Expect(err).To(Succeed())
+			Expect(ok).To(BeTrue())
+		}
+		p.Feed([]byte(in[16:]))
+		_, _, err := p.Next()
+		Expect(err).To(BeAssignableToTypeOf(&sjson.SyntaxError{}))
+		syntaxErr := err.(*sjson.SyntaxError)
+		line, column := sjson.LineColumn(in, syntaxErr.Offset)
+		Expect([]int{syntaxErr.Line(), syntaxErr.Column()}).To(Equal([]int{3, 3}))
+		Expect([]int{line, column}).To(Equal([]int{3, 3}))
+
+		p.Feed([]byte(" [4,\nx]"))
+		_, _, err = p.Next()
+		syntaxErr = err.(*sjson.SyntaxError)
+		Expect([]int{syntaxErr.Line(), syntaxErr.Column()}).To(Equal([]int{5, 1}))
+	})
+})
@@ -0,0 +1,36 @@
+package sjson
+
+import (
+	"hash/maphash"
+	"strings"
+	"sync/atomic"
+)
+
+// keyCache is a bounded cache of object keys. It is a direct mapped hash table,
+// a key replaces the previous one with the same slot, so memory is limited by the
+// number of slots. Slots are accessed atomically, so it may be shared by concurrent decoders.
+type keyCache struct {
+	seed  maphash.Seed
+	mask  uint64
+	slots []atomic.Pointer[string]
+}
+
+func newKeyCache(size int) *keyCache {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+	return &keyCache{seed: maphash.MakeSeed(), mask: uint64(n - 1), slots: make([]atomic.Pointer[string], n)}
+}
+
+// intern returns the cached string equal to key or caches a copy of key.
+// Result never refers to the memory of key.
+func (c *keyCache) intern(key string) string {
+	slot := &c.slots[maphash.String(c.seed, key)&c.mask]
+	if p := slot.Load(); p != nil && *p == key {
+		return *p
+	}
+	k := strings.Clone(key)
+	slot.Store(&k)
+	return k
+}
@@ -0,0 +1,62 @@
+package sjson
+
+import "strconv"
+
+// DefaultMaxDepth limits nesting of arrays and objects, so hostile input can not
+// exhaust the goroutine stack. It is used by Decode and other functions without
+// DecodeOptions and by Decoder with zero DecodeOptions.MaxDepth, including the zero
+// Decoder.
+const DefaultMaxDepth = 10000
+
+// A LimitError is returned when input exceeds a limit of DecodeOptions.
+// It may be tested with errors.Is against its kind, e.g. DepthLimit.
+type LimitError struct {
+	Kind   ErrorKind // exceeded limit
+	Limit  int       // value of the limit
+	Offset int       // parser position at which the limit was exceeded
+}
+
+func (e *LimitError) Error() string {
+	return "limit exceeded - " + e.Kind.Error() + " " + strconv.Itoa(e.Limit)
+}
+
+// Is reports whether target is the kind of e.
+func (e *LimitError) Is(target error) bool {
+	kind, ok := target.(ErrorKind)
+	return ok && kind == e.Kind
+}
+
+func (s *decodeState) limitError(kind ErrorKind, limit int, off int) {
+	if s.err == nil {
+		s.err = &LimitError{Kind: kind, Limit: limit, Offset: off}
+	}
+}
+
+// enter is called before decoding of array or object, it returns false if nesting
+// is too deep. Every successful enter should be paired with leave.
+func (s *decodeState) enter() bool {
+	s.depth++
+	limit := s.opts.MaxDepth
+	if limit == 0 {
+		limit = DefaultMaxDepth
+	}
+	if s.depth > limit && limit > 0 {
+		s.depth--
+		s.limitError(DepthLimit, limit, s.off)
+		return false
+	}
+	return true
+}
+
+func (s *decodeState) leave() {
+	s.depth--
+}
+
+// checkStringLen checks length of string which starts at off.
+func (s *decodeState) checkStringLen(n int, off int) bool {
+	if s.opts.MaxStringLen > 0 && n > s.opts.MaxStringLen {
+		s.limitError(StringLimit, s.opts.MaxStringLen, off)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package sjson_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+func ExpectLimitErr(kind sjson.ErrorKind, limit, offset int) func(err error) {
+	return func(err error) {
+		ExpectWithOffset(1, errors.Is(err, kind)).To(BeTrue(), "error should be %v, got %v", kind, err)
+		limitErr, ok := err.(*sjson.LimitError)
+		ExpectWithOffset(1, ok).To(BeTrue(), "error should implement *sjson.LimitError")
+		ExpectWithOffset(1, limitErr.Limit).To(Equal(limit))
+		ExpectWithOffset(1, limitErr.Offset).To(Equal(offset))
+	}
+}
+
+var _ = Describe("limits", func() {
+	Context("depth", func() {
+		nested := func(n int) string { return strings.Repeat(`[{"a":`, n) + `1` + strings.Repeat(`}]`, n) }
+		deep := nested(5000)
+		tooDeep := strings.Repeat(`[`, 1000000)
+		It("should accept nesting up to the limit", func() {
+			_, err := sjson.Decode(deep)
+			Expect(err).To(Succeed())
+		})
+		It("should reject too deep nesting", func() {
+			_, err := sjson.Decode(tooDeep)
+			ExpectLimitErr(sjson.DepthLimit, 10000, 10000)(err)
+			Expect(err).To(MatchError("limit exceeded - nesting depth 10000"))
+
+			_, errs := sjson.DecodeAll(tooDeep)
+			Expect(errs).To(HaveLen(1))
+			ExpectLimitErr(sjson.DepthLimit, 10000, 10000)(errs[0])
+
+			_, err = sjson.ParseDocument(tooDeep)
+			ExpectLimitErr(sjson.DepthLimit, 10000, 10000)(err)
+
+			err = sjson.Walk(tooDeep, sjson.NopHandler{})
+			ExpectLimitErr(sjson.DepthLimit, 10000, 10000)(err)
+		})
+		It("should use limit of Decoder", func() {
+			_, err := sjson.NewDecoder(sjson.DecodeOptions{MaxDepth: 2}).Decode(`[1,{"a":[]}]`)
+			ExpectLimitErr(sjson.DepthLimit, 2, 8)(err)
+
+			_, err = sjson.NewDecoder(sjson.DecodeOptions{}).Decode(tooDeep)
+			ExpectLimitErr(sjson.DepthLimit, 10000, 10000)(err)
+
+			_, err = sjson.NewDecoder(sjson.DecodeOptions{MaxDepth: -1}).Decode(nested(10000))
+			Expect(err).To(Succeed())
+		})
+		It("should limit zero Decoder", func() {
+			var d sjson.Decoder
+			_, err := d.Decode(tooDeep)
+			ExpectLimitErr(sjson.DepthLimit, 10000, 10000)(err)
+
+			_, err = new(sjson.Decoder).DecodeBytes([]byte(tooDeep))
+			ExpectLimitErr(sjson.DepthLimit, 10000, 10000)(err)
+		})
+	})
+	Context("resources", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{
+			MaxInputSize: 64,
+			MaxStringLen: 5,
+			MaxArrayLen:  3,
+			MaxObjectLen: 2,
+			MaxValues:    8,
+		})
+		It("should accept input within limits", func() {
+			res, err := d.Decode(`{"abcde":[1,2,"x\ty"],"b":{}}`)
+			Expect(err).To(Succeed())
+			Expect(res).To(Equal(map[string]interface{}{"abcde": []interface{}{1.0, 2.0, "x\ty"}, "b": map[string]interface{}{}}))
+		})
+		table := []struct {
+			In     string
+			Kind   sjson.ErrorKind
+			Limit  int
+			Offset int
+		}{
+			{`[` + strings.Repeat(` `, 64) + `]`, sjson.SizeLimit, 64, 64},
+			{`[1, "abcdef"]`, sjson.StringLimit, 5, 4},
+			{`{"abcdef":1}`, sjson.StringLimit, 5, 1},
+			{`["a\nbcde"]`, sjson.StringLimit, 5, 1},
+			{`["a\nbcdef\n`, sjson.StringLimit, 5, 1},
+			{`["a\nbcdef\x"]`, sjson.StringLimit, 5, 1},
+			{`["a\n\n\n\n\n`, sjson.StringLimit, 5, 1},
+			{`[1,2,3,4]`, sjson.ArrayLimit, 3, 8},
+			{`{"a":1,"b":2,"c":3}`, sjson.ObjectLimit, 2, 18},
+			{`[[1],[2],[3],{"a":4}]`, sjson.ValueLimit, 8, 18},
+		}
+		for _, t := range table {
+			t := t
+			It("should reject "+t.In, func() {
+				_, err := d.Decode(t.In)
+				ExpectLimitErr(t.Kind, t.Limit, t.Offset)(err)
+				_, errs := d.DecodeAll(t.In)
+				Expect(errs).To(HaveLen(1))
+				ExpectLimitErr(t.Kind, t.Limit, t.Offset)(errs[0])
+			})
+		}
+		It("should not limit Decode", func() {
+			_, err := sjson.Decode(`[1,2,3,4,"abcdef"]`)
+			Expect(err).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,181 @@
+package sjson
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// linesChunkSize is the initial size of the read buffer used to split
+// newline delimited input into chunks for the workers.
+var linesChunkSize = 1 << 20
+
+// linesWindow is the number of chunks per worker which may be read ahead of
+// the first undelivered one. It bounds memory held by results waiting for order.
+const linesWindow = 2
+
+type linesChunk struct {
+	seq  int    // chunk sequence number
+	line int    // number of the first line in chunk
+	data string // one or more complete lines
+}
+
+type lineResult struct {
+	lineNo int
+	val    interface{}
+	err    error
+}
+
+type linesBatch struct {
+	seq     int
+	results []lineResult
+}
+
+// DecodeLinesParallel decodes newline delimited JSON Texts (NDJSON) read from r.
+// Input is split into line-aligned chunks which are decoded concurrently by
+// workers goroutines (GOMAXPROCS if workers < 1). Function fn is called for every
+// non-empty line with its 1-based number, decoded value and decoding error.
+// Calls of fn are serialized, but not ordered, use DecodeLinesParallelOrdered
+// if order matters.
+// The returned error is the read error of r, if any.
+func DecodeLinesParallel(r io.Reader, workers int, fn func(lineNo int, v interface{}, err error)) error {
+	return decodeLines(r, workers, false, fn)
+}
+
+// DecodeLinesParallelOrdered is the same as DecodeLinesParallel, but fn is called
+// in input order.
+func DecodeLinesParallelOrdered(r io.Reader, workers int, fn func(lineNo int, v interface{}, err error)) error {
+	return decodeLines(r, workers, true, fn)
+}
+
+func decodeLines(r io.Reader, workers int, ordered bool, fn func(lineNo int, v interface{}, err error)) error {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	chunks := make(chan linesChunk, workers)
+	batches := make(chan linesBatch, workers)
+	window := make(chan struct{}, linesWindow*workers)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			decodeLinesWorker(chunks, batches)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(batches)
+	}()
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- splitLines(r, chunks, window)
+		close(chunks)
+	}()
+
+	pending := make(map[int][]lineResult)
+	next := 0
+	for b := range batches {
+		if !ordered {
+			deliverLines(b.results, fn)
+			<-window
+			continue
+		}
+		pending[b.seq] = b.results
+		for {
+			results, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			deliverLines(results, fn)
+			<-window
+			next++
+		}
+	}
+
+	return <-readErr
+}
+
+func deliverLines(results []lineResult, fn func(lineNo int, v interface{}, err error)) {
+	for _, res := range results {
+		fn(res.lineNo, res.val, res.err)
+	}
+}
+
+// splitLines reads r and sends chunks of complete lines to the workers.
+// The last line may be not terminated by newline. Every chunk takes a slot in
+// window, which is released when its results are delivered.
+func splitLines(r io.Reader, chunks chan<- linesChunk, window chan<- struct{}) error {
+	buf := make([]byte, linesChunkSize)
+	n := 0
+	seq, line := 0, 1
+	for {
+		m, err := io.ReadFull(r, buf[n:])
+		n += m
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return err
+		}
+
+		end := n
+		if !eof {
+			end = bytes.LastIndexByte(buf[:n], '\n') + 1
+		}
+		if end > 0 {
+			data := string(buf[:end])
+			window <- struct{}{}
+			chunks <- linesChunk{seq, line, data}
+			seq++
+			line += strings.Count(data, "\n")
+			n = copy(buf, buf[end:n])
+		}
+
+		if eof {
+			return nil
+		}
+		if n == len(buf) {
+			// line is longer than buffer
+			newBuf := make([]byte, 2*len(buf))
+			copy(newBuf, buf)
+			buf = newBuf
+		}
+	}
+}
+
+func decodeLinesWorker(chunks <-chan linesChunk, batches chan<- linesBatch) {
+	var state decodeState
+	for c := range chunks {
+		var results []lineResult
+		lineNo := c.line
+		data := c.data
+		for len(data) > 0 {
+			var line string
+			if i := strings.IndexByte(data, '\n'); i >= 0 {
+				line, data = data[:i], data[i+1:]
+			} else {
+				line, data = data, ""
+			}
+
+			state = decodeState{cur: line, opts: &defaultOptions}
+			state.skipSpaces()
+			if state.off < len(line) {
+				val := state.decodeValue()
+				if state.err == nil {
+					state.skipSpaces()
+					if state.off < len(line) {
+						state.error(TrailingData, "incorrect syntax - unexpected data after value")
+					}
+				}
+				results = append(results, lineResult{lineNo, val, state.err})
+			}
+			lineNo++
+		}
+		batches <- linesBatch{c.seq, results}
+	}
+}
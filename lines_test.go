@@ -0,0 +1,89 @@
+package sjson_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing/iotest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("lines", func() {
+	type line struct {
+		No  int
+		Val interface{}
+		Err error
+	}
+
+	var input string
+	var expect []line
+
+	BeforeEach(func() {
+		var b strings.Builder
+		expect = nil
+		for i := 1; i <= 1000; i++ {
+			fmt.Fprintf(&b, "{\"n\":%d}\r\n", i)
+			expect = append(expect, line{No: i, Val: map[string]interface{}{"n": float64(i)}})
+		}
+		input = b.String()
+	})
+
+	It("should decode lines in order", func() {
+		var got []line
+		err := sjson.DecodeLinesParallelOrdered(iotest.HalfReader(strings.NewReader(input)), 4, func(lineNo int, v interface{}, err error) {
+			got = append(got, line{lineNo, v, err})
+		})
+		Expect(err).To(Succeed())
+		Expect(got).To(Equal(expect))
+	})
+	It("should keep order of many chunks with slow callback", func() {
+		defer sjson.SetLinesChunkSize(64)()
+		var b strings.Builder
+		for i := 1; i <= 2000; i++ {
+			fmt.Fprintf(&b, "[%d,\"%s\"]\n", i, strings.Repeat("x", i%7*i%150))
+		}
+		var got []int
+		err := sjson.DecodeLinesParallelOrdered(strings.NewReader(b.String()), 8, func(lineNo int, v interface{}, err error) {
+			if lineNo%97 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			Expect(err).To(Succeed())
+			Expect(v.([]interface{})[0]).To(Equal(float64(lineNo)))
+			got = append(got, lineNo)
+		})
+		Expect(err).To(Succeed())
+		Expect(got).To(HaveLen(2000))
+		for i, lineNo := range got {
+			Expect(lineNo).To(Equal(i + 1))
+		}
+	})
+	It("should decode all lines", func() {
+		var got []line
+		err := sjson.DecodeLinesParallel(strings.NewReader(input), 0, func(lineNo int, v interface{}, err error) {
+			got = append(got, line{lineNo, v, err})
+		})
+		Expect(err).To(Succeed())
+		Expect(got).To(ConsistOf(expect))
+	})
+	It("should skip empty lines and report errors with line numbers", func() {
+		var got []line
+		err := sjson.DecodeLinesParallelOrdered(strings.NewReader("1\n\n  \n[2] x\ntrue"), 2, func(lineNo int, v interface{}, err error) {
+			got = append(got, line{lineNo, v, err})
+		})
+		Expect(err).To(Succeed())
+		Expect(got).To(HaveLen(3))
+		Expect(got[0]).To(Equal(line{1, 1.0, nil}))
+		Expect(got[1].No).To(Equal(4))
+		Expect(got[1].Err).To(MatchError(MatchRegexp(`unexpected data after value`)))
+		Expect(got[2]).To(Equal(line{5, true, nil}))
+	})
+	It("should return read error", func() {
+		readErr := errors.New("read failed")
+		err := sjson.DecodeLinesParallel(iotest.ErrReader(readErr), 2, func(lineNo int, v interface{}, err error) {})
+		Expect(err).To(Equal(readErr))
+	})
+})
@@ -0,0 +1,195 @@
+package sjson
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// float64pow10 contains exactly representable powers of 10.
+var float64pow10 = [...]float64{
+	1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+	1e20, 1e21, 1e22,
+}
+
+// parseFloat converts syntactically correct JSON number into float64 without allocations.
+// It returns false for hard cases (more than 19 significant digits, out of range or ambiguous
+// rounding), which should be parsed by strconv.ParseFloat.
+func parseFloat(str string) (float64, bool) {
+	i := 0
+	neg := false
+	if str[i] == '-' {
+		neg = true
+		i++
+	}
+
+	var man uint64
+	var nd, exp10 int // significant digits, decimal exponent
+	for ; i < len(str) && str[i] >= '0' && str[i] <= '9'; i++ {
+		if man == 0 && str[i] == '0' {
+			continue
+		}
+		man = man*10 + uint64(str[i]-'0')
+		nd++
+	}
+	if i < len(str) && str[i] == '.' {
+		i++
+		for ; i < len(str) && str[i] >= '0' && str[i] <= '9'; i++ {
+			exp10--
+			if man == 0 && str[i] == '0' {
+				continue
+			}
+			man = man*10 + uint64(str[i]-'0')
+			nd++
+		}
+	}
+	if nd > 19 {
+		return 0, false
+	}
+
+	if i < len(str) {
+		// exponent
+		i++
+		expNeg := false
+		if str[i] == '+' || str[i] == '-' {
+			expNeg = str[i] == '-'
+			i++
+		}
+		e := 0
+		for ; i < len(str); i++ {
+			if e < 10000 {
+				e = e*10 + int(str[i]-'0')
+			}
+		}
+		if expNeg {
+			e = -e
+		}
+		exp10 += e
+	}
+
+	if man == 0 {
+		if neg {
+			return math.Copysign(0, -1), true
+		}
+		return 0, true
+	}
+
+	// Clinger's fast path: both mantissa and power of 10 are exact, so is the result
+	if man <= 1<<53 && exp10 >= -22 && exp10 <= 22 {
+		f := float64(man)
+		if exp10 >= 0 {
+			f *= float64pow10[exp10]
+		} else {
+			f /= float64pow10[-exp10]
+		}
+		if neg {
+			f = -f
+		}
+		return f, true
+	}
+
+	return eiselLemire64(man, exp10, neg)
+}
+
+const (
+	detailedPowersOfTenMinExp10 = -348
+	detailedPowersOfTenMaxExp10 = +347
+)
+
+var (
+	detailedPowersOfTenOnce sync.Once
+	// detailedPowersOfTen contains 128-bit mantissa approximations (rounded down)
+	// to the powers of 10, as {low, high} 64-bit halves
+	detailedPowersOfTen [detailedPowersOfTenMaxExp10 - detailedPowersOfTenMinExp10 + 1][2]uint64
+)
+
+func initDetailedPowersOfTen() {
+	ten := big.NewInt(10)
+	mask := new(big.Int).SetUint64(math.MaxUint64)
+	for e := detailedPowersOfTenMinExp10; e <= detailedPowersOfTenMaxExp10; e++ {
+		p := new(big.Int).Exp(ten, big.NewInt(int64(abs(e))), nil)
+		l := p.BitLen()
+		if e >= 0 {
+			if l > 128 {
+				p.Rsh(p, uint(l-128))
+			} else {
+				p.Lsh(p, uint(128-l))
+			}
+		} else {
+			// 2^(127+l) / 10^-e is in [2^127, 2^128)
+			p.Quo(new(big.Int).Lsh(big.NewInt(1), uint(127+l)), p)
+		}
+		lo := new(big.Int).And(p, mask).Uint64()
+		hi := p.Rsh(p, 64).Uint64()
+		detailedPowersOfTen[e-detailedPowersOfTenMinExp10] = [2]uint64{lo, hi}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// eiselLemire64 computes man * 10^exp10 with the Eisel-Lemire algorithm,
+// see https://arxiv.org/abs/2101.11408 and https://github.com/google/wuffs.
+// It returns false if the result can not be computed exactly.
+func eiselLemire64(man uint64, exp10 int, neg bool) (float64, bool) {
+	if exp10 < detailedPowersOfTenMinExp10 || exp10 > detailedPowersOfTenMaxExp10 {
+		return 0, false
+	}
+	detailedPowersOfTenOnce.Do(initDetailedPowersOfTen)
+	pow := &detailedPowersOfTen[exp10-detailedPowersOfTenMinExp10]
+
+	// normalization
+	clz := bits.LeadingZeros64(man)
+	man <<= uint(clz)
+	const float64ExponentBias = 1023
+	retExp2 := uint64(217706*exp10>>16+64+float64ExponentBias) - uint64(clz)
+
+	// multiplication
+	xHi, xLo := bits.Mul64(man, pow[1])
+
+	// wider approximation
+	if xHi&0x1FF == 0x1FF && xLo+man < man {
+		yHi, yLo := bits.Mul64(man, pow[0])
+		mergedHi, mergedLo := xHi, xLo+yHi
+		if mergedLo < xLo {
+			mergedHi++
+		}
+		if mergedHi&0x1FF == 0x1FF && mergedLo+1 == 0 && yLo+man < man {
+			return 0, false
+		}
+		xHi, xLo = mergedHi, mergedLo
+	}
+
+	// shifting to 54 bits
+	msb := xHi >> 63
+	retMantissa := xHi >> (msb + 9)
+	retExp2 -= 1 ^ msb
+
+	// half-way ambiguity
+	if xLo == 0 && xHi&0x1FF == 0 && retMantissa&3 == 1 {
+		return 0, false
+	}
+
+	// from 54 to 53 bits
+	retMantissa += retMantissa & 1
+	retMantissa >>= 1
+	if retMantissa>>53 > 0 {
+		retMantissa >>= 1
+		retExp2++
+	}
+	// zero or underflow means subnormal, 0x7FF or above means Inf or NaN
+	if retExp2-1 >= 0x7FF-1 {
+		return 0, false
+	}
+	retBits := retExp2<<52 | retMantissa&0x000FFFFFFFFFFFFF
+	if neg {
+		retBits |= 0x8000000000000000
+	}
+	return math.Float64frombits(retBits), true
+}
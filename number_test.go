@@ -0,0 +1,74 @@
+package sjson_test
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+func expectSameFloat(str string) {
+	expect, err := strconv.ParseFloat(str, 64)
+	ExpectWithOffset(1, err).To(Succeed(), str)
+	res, err := sjson.Decode(str)
+	ExpectWithOffset(1, err).To(Succeed(), str)
+	ExpectWithOffset(1, math.Float64bits(res.(float64))).To(Equal(math.Float64bits(expect)), str)
+}
+
+var _ = Describe("numbers", func() {
+	It("should decode hard cases exactly", func() {
+		for _, str := range []string{
+			"0.1", "-0.0", "0e999", "1e23", "8.41e21", "9007199254740993", "9007199254740993.0",
+			"2.2250738585072011e-308", "2.2250738585072014e-308", "4.9e-324", "5e-324", "2e-324",
+			"1.7976931348623157e308", "1.7976931348623158e308", "7.038531e-26", "1e-350",
+			"0.000000000000000000000000000000000000000000001", "123456789012345678901234567890e-10",
+			"1.00000000000000011102230246251565404236316680908203125",
+			"1.00000000000000011102230246251565404236316680908203124",
+			"1.00000000000000011102230246251565404236316680908203126",
+			"9999999999999999999e-20", "18446744073709551615e0",
+			"999999999999999999", "-999999999999999999", "1000000000000000000",
+			"9223372036854775807", "9223372036854775808", "-9223372036854775808",
+			"-9223372036854775809", "18446744073709551616", "99999999999999999999",
+			"-99999999999999999999", "123456789012345678901234567890",
+		} {
+			expectSameFloat(str)
+		}
+	})
+	It("should decode random integers exactly", func() {
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 100000; i++ {
+			n := rnd.Uint64() >> uint(rnd.Intn(64))
+			str := strconv.FormatUint(n, 10)
+			if n != 0 && rnd.Intn(2) == 0 {
+				str = "-" + str
+			}
+			expectSameFloat(str)
+		}
+	})
+	It("should decode random numbers exactly", func() {
+		rnd := rand.New(rand.NewSource(1))
+		digits := func(n int) string {
+			var b strings.Builder
+			b.WriteByte(byte('1' + rnd.Intn(9)))
+			for i := 1; i < n; i++ {
+				b.WriteByte(byte('0' + rnd.Intn(10)))
+			}
+			return b.String()
+		}
+		for i := 0; i < 100000; i++ {
+			str := digits(1 + rnd.Intn(20))
+			if rnd.Intn(2) == 0 {
+				str += "." + digits(1+rnd.Intn(10))
+			}
+			str += "e" + strconv.Itoa(rnd.Intn(700)-350)
+			if _, err := strconv.ParseFloat(str, 64); err != nil {
+				continue
+			}
+			expectSameFloat(str)
+		}
+	})
+})
@@ -0,0 +1,110 @@
+package sjson
+
+import "encoding/json"
+
+// objectIndexMin is the number of members from which Object keeps the index of keys,
+// smaller objects are searched linearly.
+const objectIndexMin = 8
+
+// Member is a key and value of Object member.
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// Object is a JSON object which keeps order of its members. It is decoded instead of
+// map[string]interface{} with OrderedObjects option and encoded by encoding/json
+// with members in the same order, so decoded text may be re-encoded deterministically.
+// Zero value is an empty object.
+type Object struct {
+	members []Member
+	index   map[string]int // positions of members by key, only for large objects
+}
+
+// Len returns the number of members.
+func (o *Object) Len() int {
+	return len(o.members)
+}
+
+// Members returns members in order. The slice should not be modified.
+func (o *Object) Members() []Member {
+	return o.members
+}
+
+// Get returns value of the key.
+func (o *Object) Get(key string) (interface{}, bool) {
+	if i := o.find(key); i >= 0 {
+		return o.members[i].Value, true
+	}
+	return nil, false
+}
+
+// Set sets value of the key. The new key is added to the end, the existing one
+// keeps its position.
+func (o *Object) Set(key string, val interface{}) {
+	if i := o.find(key); i >= 0 {
+		o.members[i].Value = val
+		return
+	}
+	o.add(key, val)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, m := range o.members {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		val, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, val...)
+	}
+	return append(buf, '}'), nil
+}
+
+func (o *Object) find(key string) int {
+	if o.index != nil {
+		if i, ok := o.index[key]; ok {
+			return i
+		}
+		return -1
+	}
+	for i := range o.members {
+		if o.members[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// add appends member, key should not be present.
+func (o *Object) add(key string, val interface{}) {
+	o.members = append(o.members, Member{key, val})
+	switch {
+	case o.index != nil:
+		o.index[key] = len(o.members) - 1
+	case len(o.members) >= objectIndexMin:
+		o.index = make(map[string]int, len(o.members))
+		for i, m := range o.members {
+			o.index[m.Key] = i
+		}
+	}
+}
+
+// addOrderedMember adds member to o according to DuplicateKeys policy, off is the offset of key.
+func (s *decodeState) addOrderedMember(o *Object, key string, val interface{}, off int, collected *map[string]bool) {
+	if i := o.find(key); i >= 0 {
+		o.members[i].Value = s.duplicateValue(key, o.members[i].Value, val, off, collected)
+		return
+	}
+	o.add(key, val)
+}
@@ -0,0 +1,70 @@
+package sjson_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("ordered objects", func() {
+	d := sjson.NewDecoder(sjson.DecodeOptions{OrderedObjects: true})
+	It("should keep order of members", func() {
+		in := `{"z":1,"a":[{"y":true,"b":null}],"m":"s"}`
+		res, err := d.Decode(in)
+		Expect(err).To(Succeed())
+		obj := res.(*sjson.Object)
+		Expect(obj.Len()).To(Equal(3))
+		Expect(obj.Members()[0]).To(Equal(sjson.Member{Key: "z", Value: 1.0}))
+		Expect(obj.Members()[2]).To(Equal(sjson.Member{Key: "m", Value: "s"}))
+		v, ok := obj.Get("a")
+		Expect(ok).To(BeTrue())
+		Expect(v.([]interface{})[0].(*sjson.Object).Members()).To(Equal([]sjson.Member{{"y", true}, {"b", nil}}))
+		_, ok = obj.Get("b")
+		Expect(ok).To(BeFalse())
+
+		enc, err := json.Marshal(res)
+		Expect(err).To(Succeed())
+		Expect(string(enc)).To(Equal(in))
+	})
+	It("should look up members of large objects", func() {
+		var b strings.Builder
+		b.WriteString("{")
+		for i := 20; i > 0; i-- {
+			fmt.Fprintf(&b, `"k%d":%d,`, i, i)
+		}
+		b.WriteString(`"k5":0}`)
+		res, err := d.Decode(b.String())
+		Expect(err).To(Succeed())
+		obj := res.(*sjson.Object)
+		Expect(obj.Len()).To(Equal(20))
+		for i := 1; i <= 20; i++ {
+			v, ok := obj.Get(fmt.Sprintf("k%d", i))
+			Expect(ok).To(BeTrue())
+			if i != 5 {
+				Expect(v).To(Equal(float64(i)))
+			}
+		}
+		Expect(obj.Members()[15]).To(Equal(sjson.Member{Key: "k5", Value: 0.0}))
+	})
+	It("should set members", func() {
+		var obj sjson.Object
+		for i := 0; i < 10; i++ {
+			obj.Set(fmt.Sprint(i%5), i)
+		}
+		enc, err := json.Marshal(&obj)
+		Expect(err).To(Succeed())
+		Expect(string(enc)).To(Equal(`{"0":5,"1":6,"2":7,"3":8,"4":9}`))
+	})
+	It("should apply duplicate keys policy", func() {
+		res, err := sjson.NewDecoder(sjson.DecodeOptions{OrderedObjects: true, DuplicateKeys: sjson.DuplicateCollect}).Decode(`{"a":1,"b":2,"a":3}`)
+		Expect(err).To(Succeed())
+		Expect(res.(*sjson.Object).Members()).To(Equal([]sjson.Member{{"a", []interface{}{1.0, 3.0}}, {"b", 2.0}}))
+
+		_, err = sjson.NewDecoder(sjson.DecodeOptions{OrderedObjects: true, DuplicateKeys: sjson.DuplicateError}).Decode(`{"a":1,"a":3}`)
+		ExpectSyntaxErr(`duplicate object key`, 7)(err)
+	})
+})
@@ -1,50 +1,134 @@
 package sjson
 
 import (
-	"bytes"
+	"encoding/json"
 	"strconv"
 	"strings"
-	"unicode"
 	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
 )
 
 // PreallocateObjectElems parameter allow to tune
 // preallocated memory objects during the parsing process.
+// It is used by Decode and other functions without DecodeOptions,
+// use Decoder for per call settings.
 var PreallocateObjectElems = 2
 
+// SurrogatePolicy defines how lone UTF-16 surrogates in \u escapes are decoded.
+// Surrogate pairs are always decoded into one code point.
+type SurrogatePolicy int
+
+const (
+	// SurrogateReplace replaces lone surrogates with U+FFFD.
+	SurrogateReplace SurrogatePolicy = iota
+	// SurrogateError reports syntax error on lone surrogates.
+	SurrogateError
+	// SurrogateWTF8 passes lone surrogates through as WTF-8 (generalized UTF-8) sequences,
+	// so the string is not valid UTF-8, but the code points are preserved.
+	SurrogateWTF8
+)
+
+// DuplicatePolicy defines how repeated keys of an object are decoded.
+type DuplicatePolicy int
+
+const (
+	// DuplicateLast keeps the last value of the key.
+	DuplicateLast DuplicatePolicy = iota
+	// DuplicateFirst keeps the first value of the key.
+	DuplicateFirst
+	// DuplicateError reports syntax error at the repeated key.
+	DuplicateError
+	// DuplicateCollect collects all values of the repeated key into []interface{}.
+	// Value of the key which is not repeated is not wrapped into slice.
+	DuplicateCollect
+)
+
 // Decode function parse JSON Text into interface value. Rules are the same as in
 // encoding/json module:
+//
 //	bool, for JSON booleans
 //	float64, for JSON numbers
 //	string, for JSON strings
 //	[]interface{}, for JSON arrays
 //	map[string]interface{}, for JSON objects
 //	nil, for JSON null
-//
 func Decode(json string) (interface{}, error) {
-	state := decodeState{cur: json}
+	// defaultOptions have no input size limit and are not Strict, so decode is not needed
+	state := decodeState{cur: json, opts: &defaultOptions}
 	ret := state.decodeValue()
 	return ret, state.err
 }
 
-// A SyntaxError is a description of a JSON syntax error.
-type SyntaxError struct {
-	msg    string // description of error
-	Offset int    // current parser position at which the error occurred
+// DecodeBytes is the same as Decode, but parses JSON Text from data.
+// Decoded values never refer to data, so the caller may reuse the buffer.
+func DecodeBytes(data []byte) (interface{}, error) {
+	state := decodeState{cur: bytesString(data), opts: &bytesOptions}
+	return state.decode()
 }
 
-func (e *SyntaxError) Error() string { return e.msg }
-
 type decodeState struct {
-	cur string // current bytes
-	off int    // current offset
-	err error
+	cur  string // current bytes
+	off  int    // current offset
+	err  error
+	opts *DecodeOptions
+
+	shapes  *shapeTable // observed container sizes, if adaptive presizing is enabled
+	path    uint64      // hash of the current nesting path
+	keys    *keyCache   // cache of object keys, if interning is enabled
+	arena   *arena      // allocator of slices and strings, if arena is enabled
+	scratch *scratch    // reusable buffers of Decoder
+
+	depth  int          // nesting level of arrays and objects
+	values int          // number of decoded values, counted only if MaxValues is set
+	extra  *decodeExtra // state of DecodeAll and DecodeWithPositions, nil for other calls
 }
 
-func (s *decodeState) error(msg string) {
+// decodeExtra is the state of rarely used decoding modes. It is kept out of decodeState,
+// so calls which do not need it do not pay for its initialization.
+type decodeExtra struct {
+	recover bool    // continue after errors in containers, see DecodeAll
+	errs    []error // recovered errors
+	errPos  linePos // line and column of the last error
+
+	positions map[string]Span // spans of values by JSON Pointer, see DecodeWithPositions
+	pointer   []byte          // JSON Pointer of the current value, if positions are tracked
+}
+
+func (s *decodeState) decode() (interface{}, error) {
+	if s.opts.MaxInputSize > 0 && len(s.cur) > s.opts.MaxInputSize {
+		s.limitError(SizeLimit, s.opts.MaxInputSize, s.opts.MaxInputSize)
+		return nil, s.err
+	}
+	ret := s.decodeValue()
+	if s.err == nil && s.opts.Strict {
+		s.skipSpaces()
+		if len(s.cur) > s.off {
+			s.error(TrailingData, "incorrect syntax - unexpected data after value")
+		}
+	}
+	return ret, s.err
+}
+
+func (s *decodeState) error(kind ErrorKind, msg string) {
+	s.errorAt(kind, msg, s.off)
+}
+
+func (s *decodeState) errorAt(kind ErrorKind, msg string, off int) {
 	if s.err == nil {
-		s.err = &SyntaxError{msg, s.off}
+		if kind == UnexpectedToken && off >= len(s.cur) {
+			kind = UnexpectedEOF
+		}
+		s.err = s.syntaxError(kind, msg, off)
+	}
+}
+
+// copyString returns a copy of str which does not refer to the input.
+func (s *decodeState) copyString(str string) string {
+	if s.arena != nil {
+		return s.arena.copyString(str)
 	}
+	return strings.Clone(str)
 }
 
 func (s *decodeState) skipSpaces() {
@@ -62,8 +146,9 @@ func (s *decodeState) skipSpaces() {
 const arr0Size int = 8
 
 func (s *decodeState) decodeSlice() []interface{} {
-	var arr0 [arr0Size]interface{}
-
+	if s.scratch != nil {
+		return s.decodeSliceScratch()
+	}
 	s.skipSpaces()
 
 	if len(s.cur) > s.off && s.cur[s.off] == ']' {
@@ -71,135 +156,247 @@ func (s *decodeState) decodeSlice() []interface{} {
 		return []interface{}{}
 	}
 
-	arr0[0] = s.decodeValue()
-	if s.err != nil {
-		return arr0[:1]
+	arr := make([]interface{}, 0, arr0Size)
+	for {
+		var mark, ptr int
+		if s.extra != nil {
+			mark, ptr = s.enterElement(len(arr), "")
+		}
+		val := s.decodeValue()
+		if s.extra != nil {
+			s.leaveElement(mark, ptr, len(arr), "")
+		}
+		if s.err != nil {
+			s.addPath(mark, strconv.Itoa(len(arr)))
+			if !s.recoverError(']') {
+				if len(arr) == 0 {
+					arr = append(arr, val)
+				}
+				return arr
+			}
+		}
+		arr = append(arr, val)
+		if s.opts.MaxArrayLen > 0 && len(arr) > s.opts.MaxArrayLen {
+			s.limitError(ArrayLimit, s.opts.MaxArrayLen, s.off)
+			return arr
+		}
+		s.skipSpaces()
+		if len(s.cur) > s.off && s.cur[s.off] == ',' {
+			s.off++
+			continue
+		}
+		if !s.nextElement() {
+			return arr
+		}
 	}
+}
 
-	var i int = 1
-SMALL:
-	for len(s.cur) > s.off {
+// nextElement moves after ',' or ']' following an array element, it returns true
+// if there is the next element. The common case of ',' is checked by callers.
+func (s *decodeState) nextElement() bool {
+	for {
 		s.skipSpaces()
 		if len(s.cur) <= s.off {
-			s.error("incorrect syntax - incomplete array")
-			return arr0[:i]
+			s.error(UnexpectedToken, "incorrect syntax - incomplete array")
+			return false
 		}
 		switch s.cur[s.off] {
 		case ']':
 			s.off++
-			return arr0[:i]
+			return false
 		case ',':
 			s.off++
-			s.skipSpaces()
-			if i >= arr0Size {
-				break SMALL
-			}
-		default:
-			s.error("incorrect syntax - incomplete array")
-			return arr0[:i]
+			return true
 		}
-
-		val := s.decodeValue()
-		if s.err != nil {
-			return arr0[:i]
+		s.error(UnexpectedToken, "incorrect syntax - incomplete array")
+		if !s.recoverError(']') {
+			return false
 		}
-		arr0[i] = val
-		i++
-
-		s.skipSpaces()
 	}
+}
 
-	if len(s.cur) <= s.off {
-		s.error("incorrect syntax - incomplete array")
-		return arr0[:i]
+func (s *decodeState) decodeObject() interface{} {
+	size := s.opts.PreallocateObjectElems
+	if size == 0 {
+		size = PreallocateObjectElems
 	}
-
-	arr := arr0[:]
-
-	for len(s.cur) > s.off {
-		if s.cur[s.off] == ']' {
-			s.off++
-			return arr
-		}
-
-		val := s.decodeValue()
-		if s.err != nil {
-			return arr
-		}
-		arr = append(arr, val)
-
-		s.skipSpaces()
-		if len(s.cur) > s.off && s.cur[s.off] == ',' {
-			s.off++
-			s.skipSpaces()
+	path := s.path
+	if s.shapes != nil {
+		size = s.shapes.hint(path, size)
+	}
+	var obj map[string]interface{}
+	var ordered *Object
+	var base int // start of members on scratch stack, if objects are built
+	switch {
+	case s.opts.Builder != nil:
+		if s.scratch == nil {
+			s.scratch = new(scratch)
 		}
+		base = len(s.scratch.members)
+	case s.opts.OrderedObjects:
+		ordered = &Object{members: make([]Member, 0, size)}
+	default:
+		obj = make(map[string]interface{}, size)
 	}
 
-	s.error("incorrect syntax - incomplete array")
-	return arr
-}
-
-func (s *decodeState) decodeObject() map[string]interface{} {
-	obj := make(map[string]interface{}, PreallocateObjectElems)
-
+	var n int // number of members
+	var comma bool
+	var collected map[string]bool // keys with collected values, see DuplicateCollect
 	for {
 		s.skipSpaces()
 
 		if len(s.cur) <= s.off {
-			s.error("incorrect syntax - object")
-			return obj
+			s.error(UnexpectedToken, "incorrect syntax - object")
+			return s.objectValue(obj, ordered, base, collected)
 		}
 
 		switch s.cur[s.off] {
 		case '}':
+			if !s.checkObjectComma('}', n, comma) {
+				break
+			}
 			s.off++
-			return obj
+			if s.shapes != nil {
+				s.path = path
+				s.shapes.observe(path, n)
+			}
+			return s.objectValue(obj, ordered, base, collected)
 		case '"':
+			if !s.checkObjectComma('"', n, comma) {
+				break
+			}
+			keyOff := s.off
 			s.off++
-			key := s.decodeString()
+			key := s.decodeKey()
 			if s.err != nil {
-				return obj
+				break
 			}
 			s.skipSpaces()
 			if len(s.cur) > s.off && s.cur[s.off] == ':' {
 				s.off++
 			} else {
-				s.error("incorrect syntax - expect ':' after object key")
-				return obj
+				s.error(UnexpectedToken, "incorrect syntax - expect ':' after object key")
+				break
+			}
+			if s.shapes != nil {
+				s.path = memberPath(path, key)
+			}
+			var mark, ptr int
+			if s.extra != nil {
+				mark, ptr = s.enterElement(-1, key)
+			}
+			val := s.decodeValue()
+			if ordered != nil {
+				s.addOrderedMember(ordered, key, val, keyOff, &collected)
+			} else if obj == nil {
+				s.addBuiltMember(base, key, val, keyOff, &collected)
+			} else if s.opts.DuplicateKeys == DuplicateLast {
+				obj[key] = val
+			} else {
+				s.addMember(obj, key, val, keyOff, &collected)
+			}
+			if n++; s.opts.MaxObjectLen > 0 && n > s.opts.MaxObjectLen {
+				s.limitError(ObjectLimit, s.opts.MaxObjectLen, s.off)
+			}
+			if s.extra != nil {
+				s.leaveElement(mark, ptr, -1, key)
+			}
+			if s.err != nil {
+				s.addPath(mark, key)
+				break
 			}
-			obj[key] = s.decodeValue()
 			s.skipSpaces()
-			if len(s.cur) > s.off && s.cur[s.off] == ',' {
+			comma = len(s.cur) > s.off && s.cur[s.off] == ','
+			if comma {
 				s.off++
 			}
+			continue
 		default:
-			s.error("incorrect syntax - expect object key or incomplete object")
-			return obj
+			s.error(UnexpectedToken, "incorrect syntax - expect object key or incomplete object")
+		}
+
+		// error in member
+		if !s.recoverError('}') {
+			return s.objectValue(obj, ordered, base, collected)
+		}
+		comma = s.cur[s.off] == ','
+		if comma {
+			s.off++
 		}
 	}
+}
 
-	return obj
+// checkObjectComma checks the separator before c, which is '"' of the next key or '}',
+// after n members of object, comma tells if the last member is followed by ','.
+// Missing and trailing commas are accepted unless Strict.
+func (s *decodeState) checkObjectComma(c byte, n int, comma bool) bool {
+	if !s.opts.Strict {
+		return true
+	}
+	if c == '}' && comma {
+		s.error(UnexpectedToken, "incorrect syntax - expect object key after ','")
+		return false
+	}
+	if c == '"' && n > 0 && !comma {
+		s.error(UnexpectedToken, "incorrect syntax - expect ',' between object members")
+		return false
+	}
+	return true
 }
 
-func findStringSpecial(s string) int {
-	// TODO(vovkasm): rewrite this with asm (may be SIMD optimized)
-	quotePos := strings.IndexByte(s, '"')
-	if quotePos < 0 {
-		return -1
+// objectValue returns the decoded object, which is map, *Object if objects are ordered,
+// or value of Builder, base and collected are used only by Builder.
+func (s *decodeState) objectValue(obj map[string]interface{}, ordered *Object, base int, collected map[string]bool) interface{} {
+	if ordered != nil {
+		return ordered
 	}
-	esqPos := strings.IndexByte(s[:quotePos], '\\')
-	if esqPos < 0 {
-		return quotePos
+	if obj == nil {
+		return s.buildObject(base, collected)
 	}
-	return esqPos
+	return obj
 }
 
+// decodeString decodes string after the open quote.
 func (s *decodeState) decodeString() string {
 	curPos := findStringSpecial(s.cur[s.off:])
+	// fast path (no escaping and no options which change result)
+	if curPos >= 0 && s.cur[s.off+curPos] == '"' && s.scratch == nil && !s.opts.CopyStrings && s.opts.MaxStringLen == 0 {
+		str := s.cur[s.off : s.off+curPos]
+		s.off += curPos + 1
+		return str
+	}
+	str, escaped := s.scanStringFrom(curPos)
+	if (escaped && s.scratch != nil) || (!escaped && s.opts.CopyStrings) {
+		return s.copyString(str)
+	}
+	return str
+}
+
+// decodeKey decodes object key after the open quote.
+func (s *decodeState) decodeKey() string {
+	if s.keys == nil {
+		return s.decodeString()
+	}
+	str, _ := s.scanString()
+	if s.err != nil {
+		return ""
+	}
+	return s.keys.intern(str)
+}
+
+// scanString reads string after the open quote. The string without escapes is a substring
+// of the input, otherwise it is unescaped into the scratch buffer if decoder has it,
+// so it is valid only until the next call.
+func (s *decodeState) scanString() (str string, escaped bool) {
+	return s.scanStringFrom(findStringSpecial(s.cur[s.off:]))
+}
+
+// scanStringFrom is scanString with curPos, the result of findStringSpecial at s.off.
+func (s *decodeState) scanStringFrom(curPos int) (str string, escaped bool) {
 	if curPos < 0 {
 		s.off = len(s.cur)
-		s.error("incorrect syntax - expect close quote")
-		return ""
+		s.error(UnexpectedToken, "incorrect syntax - expect close quote")
+		return "", false
 	}
 
 	fistChunk := s.cur[s.off : s.off+curPos]
@@ -207,100 +404,174 @@ func (s *decodeState) decodeString() string {
 
 	// fast path (found closing quote and no escaping)
 	if s.cur[s.off] == '"' {
+		if !s.checkStringLen(curPos, s.off-curPos-1) {
+			return "", false
+		}
 		s.off++
-		return fistChunk
+		return fistChunk, false
 	}
+	start := s.off - curPos - 1
 
-	// full unescape
-	// TODO(vovkasm): benchmark and compare with custom implementation without bytes.Buffer
-	var b bytes.Buffer
-	b.WriteString(fistChunk)
+	var buf []byte
+	if s.scratch != nil {
+		buf = s.scratch.buf[:0]
+	} else {
+		// full unescape, result is never longer than the source, so size it up to the next quote
+		size := len(fistChunk) + 16
+		if pos := strings.IndexByte(s.cur[s.off:], '"'); pos >= 0 {
+			size += pos
+		}
+		buf = make([]byte, 0, size)
+	}
+	buf = append(buf, fistChunk...)
 
 	for {
 		switch s.cur[s.off] {
 		case '"':
+			if s.scratch != nil {
+				s.scratch.buf = buf
+			}
 			s.off++
-			return b.String()
+			return unsafe.String(unsafe.SliceData(buf), len(buf)), true
 		case '\\':
-			s.off++
-			r := s.decodeStringEscape()
-			if s.err != nil {
-				return ""
-			}
-			if utf16.IsSurrogate(r) && s.cur[s.off] == '\\' {
-				s.off++
-				r2 := s.decodeStringEscape()
-				if s.err != nil {
-					return ""
-				}
-
-				r = utf16.DecodeRune(r, r2)
+			buf = s.unescapeRun(buf)
+			if s.err != nil || !s.checkStringLen(len(buf), start) {
+				return "", false
 			}
-			b.WriteRune(r)
+		default:
+			s.error(InvalidCharacter, "incorrect syntax - expect escape sequence for control character")
+			return "", false
 		}
 		pos := findStringSpecial(s.cur[s.off:])
 		if pos < 0 {
 			s.off = len(s.cur)
-			s.error("incorrect syntax - expect close quote")
-			return ""
+			s.error(UnexpectedToken, "incorrect syntax - expect close quote")
+			return "", false
 		}
-		b.WriteString(s.cur[s.off : s.off+pos])
+		buf = append(buf, s.cur[s.off:s.off+pos]...)
 		s.off += pos
+		if !s.checkStringLen(len(buf), start) {
+			return "", false
+		}
 	}
+}
 
-	return ""
+// unescapeTable maps simple escape characters to its values, 0 for invalid escapes.
+var unescapeTable = [256]byte{
+	'"':  '"',
+	'\\': '\\',
+	'/':  '/',
+	'\'': '\'',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
 }
 
-func (s *decodeState) decodeStringEscape() (r rune) {
-	switch s.cur[s.off] {
-	case '"', '\\', '/', '\'':
-		r = rune(s.cur[s.off])
-		s.off++
-	case 'b':
-		r = '\b'
-		s.off++
-	case 'f':
-		r = '\f'
+// unescapeRun appends to buf the sequence of escapes at s.off.
+func (s *decodeState) unescapeRun(buf []byte) []byte {
+	for len(s.cur) > s.off && s.cur[s.off] == '\\' {
+		start := s.off
 		s.off++
-	case 'n':
-		r = '\n'
-		s.off++
-	case 'r':
-		r = '\r'
-		s.off++
-	case 't':
-		r = '\t'
-		s.off++
-	case 'u':
+		if len(s.cur) <= s.off {
+			s.error(UnexpectedToken, "incorrect syntax - expect close quote")
+			return buf
+		}
+		c := s.cur[s.off]
 		s.off++
-		if len(s.cur) >= s.off+4 {
-			rr, err := strconv.ParseUint(s.cur[s.off:s.off+4], 16, 64)
-			s.off += 4
-			if err != nil {
-				s.error("incorrect syntax - expect hex number")
+		if c != 'u' {
+			if unescapeTable[c] == 0 || (c == '\'' && s.opts.Strict) {
+				s.error(InvalidEscape, "incorrect syntax - expect escape sequence")
+				return buf
 			}
-			r = rune(rr)
-		} else {
-			s.error("incorrect syntax - expect 4-digit hex number")
-			r = unicode.ReplacementChar
+			buf = append(buf, unescapeTable[c])
+			continue
 		}
-	default:
-		s.off++
-		s.error("incorrect syntax - expect escape sequence")
-		r = unicode.ReplacementChar
+
+		r := s.decodeHex4()
+		if s.err != nil {
+			return buf
+		}
+		if utf16.IsSurrogate(r) {
+			var paired bool
+			if r, paired = s.decodeSurrogatePair(r); !paired {
+				buf = s.appendLoneSurrogate(buf, r, start)
+				continue
+			}
+		}
+		buf = utf8.AppendRune(buf, r)
 	}
-	return
+	return buf
 }
 
-const charToNum64 int64 = 0x0F
+// decodeSurrogatePair combines high surrogate r with the following \u escape of low surrogate.
+func (s *decodeState) decodeSurrogatePair(r rune) (rune, bool) {
+	if r >= 0xDC00 || len(s.cur) < s.off+6 || s.cur[s.off] != '\\' || s.cur[s.off+1] != 'u' {
+		return r, false
+	}
+	r2, ok := parseHex4(s.cur[s.off+2 : s.off+6])
+	if !ok || r2 < 0xDC00 || r2 > 0xDFFF {
+		return r, false
+	}
+	s.off += 6
+	return utf16.DecodeRune(r, r2), true
+}
 
-func (s *decodeState) decodeNumber() float64 {
-	var startPos = s.off
-	var signMul int64 = 1
+// appendLoneSurrogate appends lone surrogate r according to LoneSurrogates policy,
+// off is the offset of its escape.
+func (s *decodeState) appendLoneSurrogate(buf []byte, r rune, off int) []byte {
+	switch s.opts.LoneSurrogates {
+	case SurrogateError:
+		s.errorAt(InvalidEscape, "incorrect syntax - lone surrogate in escape sequence", off)
+		return buf
+	case SurrogateWTF8:
+		// the same encoding as UTF-8 has for other code points, which is forbidden for surrogates
+		return append(buf, 0xE0|byte(r>>12), 0x80|byte(r>>6)&0x3F, 0x80|byte(r)&0x3F)
+	}
+	return utf8.AppendRune(buf, utf8.RuneError)
+}
+
+// decodeHex4 decodes 4 hex digits of \u escape.
+func (s *decodeState) decodeHex4() rune {
+	if len(s.cur) < s.off+4 {
+		s.error(InvalidEscape, "incorrect syntax - expect 4-digit hex number")
+		return utf8.RuneError
+	}
+	r, ok := parseHex4(s.cur[s.off : s.off+4])
+	s.off += 4
+	if !ok {
+		s.error(InvalidEscape, "incorrect syntax - expect hex number")
+		return utf8.RuneError
+	}
+	return r
+}
+
+func parseHex4(hex string) (r rune, ok bool) {
+	for i := 0; i < 4; i++ {
+		c := hex[i]
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c -= 'a' - 10
+		case c >= 'A' && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
 
+const charToNum64 int64 = 0x0F
+
+// scanNumber checks syntax of number and moves after it, it returns true if number
+// has fractional or exponential part.
+func (s *decodeState) scanNumber() (slowParsing bool) {
 	// sign
 	if s.cur[s.off] == '-' {
-		signMul = -1
 		s.off++
 	}
 
@@ -314,12 +585,11 @@ func (s *decodeState) decodeNumber() float64 {
 	} else if len(s.cur) > s.off && s.cur[s.off] == '0' {
 		s.off++
 	} else {
-		s.error("incorrect number - expected digit")
-		return 0.0
+		s.error(BadNumber, "incorrect number - expected digit")
+		return
 	}
 
 	// - fractional
-	var slowParsing bool
 	if len(s.cur) > s.off && s.cur[s.off] == '.' {
 		slowParsing = true
 		s.off++
@@ -329,8 +599,8 @@ func (s *decodeState) decodeNumber() float64 {
 				s.off++
 			}
 		} else {
-			s.error("incorrect number - expected fractional")
-			return 0.0
+			s.error(BadNumber, "incorrect number - expected fractional")
+			return
 		}
 	}
 
@@ -348,12 +618,29 @@ func (s *decodeState) decodeNumber() float64 {
 				s.off++
 			}
 		} else {
-			s.error("incorrect number - expected digit in exponential")
-			return 0.0
+			s.error(BadNumber, "incorrect number - expected digit in exponential")
+			return
 		}
 	}
+	return
+}
+
+func (s *decodeState) decodeNumber() float64 {
+	var startPos = s.off
+	var signMul int64 = 1
+
+	slowParsing := s.scanNumber()
+	if s.err != nil {
+		return 0.0
+	}
+	digits := s.off - startPos
+	if s.cur[startPos] == '-' {
+		signMul = -1
+		digits--
+	}
 
-	if !slowParsing {
+	// int64 holds any integer up to 18 digits, longer ones may overflow it
+	if !slowParsing && digits <= 18 {
 		if signMul < 0 {
 			startPos++
 		}
@@ -379,55 +666,143 @@ func (s *decodeState) decodeNumber() float64 {
 		return float64(signMul * acc)
 	}
 
-	val, err := strconv.ParseFloat(string(s.cur[startPos:s.off]), 64)
+	if val, ok := parseFloat(s.cur[startPos:s.off]); ok {
+		return val
+	}
+	// syntax is already checked, so it fails only if number is out of range of float64
+	val, err := strconv.ParseFloat(s.cur[startPos:s.off], 64)
 	if err != nil {
-		s.err = err
+		s.errorAt(BadNumber, "incorrect number - value out of range", startPos)
 	}
 
 	return val
 }
 
 func (s *decodeState) decodeValue() interface{} {
+	if s.opts.MaxValues > 0 {
+		if s.values++; s.values > s.opts.MaxValues {
+			s.skipSpaces()
+			s.limitError(ValueLimit, s.opts.MaxValues, s.off)
+			return nil
+		}
+	}
 	s.skipSpaces()
 	if len(s.cur) <= s.off {
-		s.error("incorrect syntax - expect value")
+		s.error(UnexpectedToken, "incorrect syntax - expect value")
 		return nil
 	}
+	start := s.off
+	var val interface{}
 	switch s.cur[s.off] {
 	case '"':
 		s.off++
-		return s.decodeString()
+		val = s.decodeString()
 	case '{':
+		if !s.enter() {
+			return nil
+		}
 		s.off++
-		return s.decodeObject()
+		obj := s.decodeObject()
+		s.leave()
+		if s.opts.ObjectHook != nil && s.opts.Builder == nil && s.err == nil {
+			val = s.objectHook(obj)
+		} else {
+			val = obj
+		}
 	case '[':
+		if !s.enter() {
+			return nil
+		}
 		s.off++
-		return s.decodeSlice()
+		arr := s.decodeSlice()
+		s.leave()
+		if s.opts.Builder != nil {
+			val = s.buildArray(arr)
+		} else if s.opts.ArrayHook != nil && s.err == nil {
+			val = s.arrayHook(arr)
+		} else {
+			val = arr
+		}
 	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-		return s.decodeNumber()
+		if s.opts.NumberMode == NumberJSON {
+			if s.scanNumber(); s.err != nil {
+				return nil
+			}
+			if s.opts.CopyStrings {
+				val = json.Number(s.copyString(s.cur[start:s.off]))
+			} else {
+				val = json.Number(s.cur[start:s.off])
+			}
+		} else {
+			val = s.decodeNumber()
+		}
 	case 't':
 		if len(s.cur) >= s.off+4 && s.cur[s.off:s.off+4] == "true" {
 			s.off += 4
-			return true
+			val = true
 		} else {
-			s.error("'true' expected")
+			s.error(UnexpectedToken, "'true' expected")
 		}
 	case 'f':
 		if len(s.cur) >= s.off+5 && s.cur[s.off:s.off+5] == "false" {
 			s.off += 5
-			return false
+			val = false
 		} else {
-			s.error("'false' expected")
+			s.error(UnexpectedToken, "'false' expected")
 		}
 	case 'n':
 		if len(s.cur) >= s.off+4 && s.cur[s.off:s.off+4] == "null" {
 			s.off += 4
-			return nil
 		} else {
-			s.error("'null' expected")
+			s.error(UnexpectedToken, "'null' expected")
 		}
 	default:
-		s.error("incorrect syntax - unrecognized token")
+		s.error(UnexpectedToken, "incorrect syntax - unrecognized token")
+	}
+	if s.err == nil && (s.opts.Builder != nil || s.extra != nil) {
+		return s.finishValue(start, val)
+	}
+	return val
+}
+
+// finishValue passes scalar value decoded from start to Builder and records position
+// of the value, if positions are tracked.
+func (s *decodeState) finishValue(start int, val interface{}) interface{} {
+	if s.opts.Builder != nil {
+		val = s.buildScalar(start, val)
+	}
+	if s.extra != nil && s.extra.positions != nil {
+		s.extra.positions[string(s.extra.pointer)] = Span{start, s.off}
+	}
+	return val
+}
+
+// addMember adds member to obj according to DuplicateKeys policy, off is the offset of key.
+func (s *decodeState) addMember(obj map[string]interface{}, key string, val interface{}, off int, collected *map[string]bool) {
+	if prev, dup := obj[key]; dup {
+		val = s.duplicateValue(key, prev, val, off, collected)
 	}
-	return nil
+	obj[key] = val
+}
+
+// duplicateValue returns value of the repeated key according to DuplicateKeys policy,
+// collected holds keys which values are already collected into slice.
+func (s *decodeState) duplicateValue(key string, prev, val interface{}, off int, collected *map[string]bool) interface{} {
+	switch s.opts.DuplicateKeys {
+	case DuplicateFirst:
+		return prev
+	case DuplicateError:
+		s.errorAt(DuplicateKey, "incorrect syntax - duplicate object key", off)
+		return prev
+	case DuplicateCollect:
+		if (*collected)[key] {
+			return append(prev.([]interface{}), val)
+		}
+		if *collected == nil {
+			*collected = make(map[string]bool)
+		}
+		(*collected)[key] = true
+		return []interface{}{prev, val}
+	}
+	return val
 }
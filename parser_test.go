@@ -2,6 +2,7 @@ package sjson_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"testing"
@@ -65,7 +66,7 @@ var _ = Describe("parser", func() {
 		{"errors in numbers", `-0.`, Equal(0.0), ExpectErr(`incorrect number`)},
 		{"errors in numbers", `-0e`, Equal(0.0), ExpectSyntaxErr(`incorrect number`, 3)},
 		{"errors in numbers", `-e+1`, Equal(0.0), ExpectSyntaxErr(`incorrect number`, 1)},
-		{"parse flost error", `11222132131232132132132321.1e100000`, Equal(math.Inf(1)), ExpectErr(`value out of range`)},
+		{"parse flost error", `11222132131232132132132321.1e100000`, Equal(math.Inf(1)), ExpectSyntaxErr(`value out of range`, 0)},
 		// strings
 		{"can decode empty string", `""`, Equal(""), ExpectNoErr()},
 		{"can decode simple string", `"abc"`, Equal("abc"), ExpectNoErr()},
@@ -77,6 +78,10 @@ var _ = Describe("parser", func() {
 		{"can decode escapes4", `"abc\\"`, Equal(`abc\`), ExpectNoErr()},
 		{"can decode escapes5", `"abc\\\"qwe"`, Equal(`abc\"qwe`), ExpectNoErr()},
 		{"can decode escapes from extended range", `"\ud800\udd40"`, Equal("𐅀"), ExpectNoErr()}, // Greek Acrophonic Attic One Quarter
+		{"high surrogate followed by non-surrogate escape", `"\ud800\u0041"`, Equal("\uFFFDA"), ExpectNoErr()},
+		{"lone low surrogate", `"\udd40x"`, Equal("\uFFFDx"), ExpectNoErr()},
+		{"lone high surrogate at the end", `"\ud800"`, Equal("\uFFFD"), ExpectNoErr()},
+		{"run of escapes", `"a\u00e9\u00E9\n\t\\\/b"`, Equal("aéé\n\t\\/b"), ExpectNoErr()},
 		{"errors in strings", `"ab`, Equal(""), ExpectSyntaxErr(`incorrect syntax`, 3)},
 		{"errors in strings 2", `"ab\"cd`, Equal(""), ExpectSyntaxErr(`incorrect syntax`, 7)},
 		{"many escapes", `"bbb\"\\\b\f\n\r\tあeee"`, Equal("bbb\"\\\b\f\n\r\tあeee"), ExpectNoErr()},
@@ -85,8 +90,9 @@ var _ = Describe("parser", func() {
 		{"invalid escapes2", `"\`, Equal(""), ExpectSyntaxErr("expect close quote", 2)},
 		{"invalid escapes3", `"\u"`, Equal(""), ExpectSyntaxErr("expect 4-digit hex number", 3)},
 		{"invalid escapes4", `"\uaaxx"`, Equal(""), ExpectSyntaxErr("expect hex number", 7)},
-		//TODO: do not allow control characters for fast paths :-(
 		{"control characters invalid in strings", "\"\r\\\r\"", Equal(``), ExpectErr("incorrect syntax - expect escape sequence")},
+		{"control characters invalid in fast path", "\"ab\tc\"", Equal(``), ExpectSyntaxErr("expect escape sequence for control character", 3)},
+		{"control characters invalid after escape", "\"\\n\x00\"", Equal(``), ExpectSyntaxErr("expect escape sequence for control character", 3)},
 		// objects
 		{"can decode empty object", `{}`, Equal(map[string]interface{}{}), ExpectNoErr()},
 		{"can decode simple object", `{"key1":"val1"}`, Equal(map[string]interface{}{"key1": "val1"}), ExpectNoErr()},
@@ -107,6 +113,8 @@ var _ = Describe("parser", func() {
 		{"incomplete array after first item", `[true `, Equal([]interface{}{true}), ExpectSyntaxErr(`incomplete array`, 6)},
 		{"error in second item", `[true, tru]`, Equal([]interface{}{true}), ExpectSyntaxErr(`'true' expected`, 7)},
 		{"long incomplete array", `[1,2,3,4,5,6,7,8,9 `, Equal([]interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0}), ExpectSyntaxErr(`incomplete array`, 19)},
+		{"missing comma in long array", `[1,2,3,4,5,6,7,8,9 10]`, Equal([]interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0}), ExpectSyntaxErr(`incomplete array`, 19)},
+		{"trailing comma in long array", `[1,2,3,4,5,6,7,8,9,]`, Equal([]interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0}), ExpectSyntaxErr(`unrecognized token`, 19)},
 		{"error in long incomplete array", `[1,2,3,4,5,6,7,8,tru] `, Equal([]interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0}), ExpectSyntaxErr(`'true' expected`, 17)},
 		// spaces
 		{"skip various spaces", " \u000a\u000d\u0009true", BeTrue(), ExpectNoErr()},
@@ -154,3 +162,62 @@ func Example() {
 	fmt.Printf("Hi, %s!\n", obj.(map[string]interface{})["name"])
 	// Output: Hi, John!
 }
+
+var _ = Describe("lone surrogates", func() {
+	It("should be replaced by default", func() {
+		res, err := sjson.Decode(`"a\udd40\ud800"`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal("a��"))
+	})
+	It("should be reported as error", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{LoneSurrogates: sjson.SurrogateError})
+		_, err := d.Decode(`"a\ud800A"`)
+		ExpectSyntaxErr(`lone surrogate`, 2)(err)
+		_, err = d.Decode(`"ab\ud800\u0041"`)
+		ExpectSyntaxErr(`lone surrogate`, 3)(err)
+		res, err := d.Decode(`"\ud800\udd40"`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal("𐅀"))
+	})
+	It("should pass through as WTF-8", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{LoneSurrogates: sjson.SurrogateWTF8})
+		res, err := d.Decode(`"\ud800A\udd40"`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal("\xed\xa0\x80A\xed\xb5\x80"))
+	})
+})
+
+var _ = Describe("duplicate keys", func() {
+	in := `{"a":1,"b":[2],"a":{"c":3},"b":4,"a":5}`
+	It("should keep the last value by default", func() {
+		res, err := sjson.Decode(in)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(map[string]interface{}{"a": 5.0, "b": 4.0}))
+	})
+	It("should keep the first value", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{DuplicateKeys: sjson.DuplicateFirst})
+		res, err := d.Decode(in)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(map[string]interface{}{"a": 1.0, "b": []interface{}{2.0}}))
+	})
+	It("should be reported as error", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{DuplicateKeys: sjson.DuplicateError})
+		_, err := d.Decode(in)
+		ExpectSyntaxErr(`duplicate object key`, 15)(err)
+		Expect(errors.Is(err, sjson.DuplicateKey)).To(BeTrue())
+		Expect(err.(*sjson.SyntaxError).Pointer()).To(Equal("/a"))
+
+		_, errs := d.DecodeAll(`[{"a":1,"a":2,"b":3,"b":4}]`)
+		Expect(errs).To(HaveLen(2))
+		ExpectSyntaxErr(`duplicate object key`, 20)(errs[1])
+	})
+	It("should collect all values", func() {
+		d := sjson.NewDecoder(sjson.DecodeOptions{DuplicateKeys: sjson.DuplicateCollect})
+		res, err := d.Decode(in)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(map[string]interface{}{
+			"a": []interface{}{1.0, map[string]interface{}{"c": 3.0}, 5.0},
+			"b": []interface{}{[]interface{}{2.0}, 4.0},
+		}))
+	})
+})
@@ -0,0 +1,90 @@
+package sjson
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Span is the position of a value in JSON Text, Start is the offset of its first byte
+// and End is the offset after its last byte.
+type Span struct {
+	Start int
+	End   int
+}
+
+// DecodeWithPositions parses JSON Text like Decode and also returns spans of all decoded
+// values by its JSON Pointer (RFC 6901), "" is the pointer of the root value. It allows
+// to report positions of problems found in decoded data, see LineColumn.
+func DecodeWithPositions(json string) (interface{}, map[string]Span, error) {
+	state := decodeState{cur: json, opts: &defaultOptions, extra: &decodeExtra{positions: make(map[string]Span)}}
+	val, err := state.decode()
+	return val, state.extra.positions, err
+}
+
+// DecodeWithPositions parses JSON Text in the same way as DecodeWithPositions function does.
+func (d *Decoder) DecodeWithPositions(json string) (interface{}, map[string]Span, error) {
+	state := d.acquire(json)
+	state.extra = &decodeExtra{positions: make(map[string]Span)}
+	val, err := state.decode()
+	d.release(&state)
+	return val, state.extra.positions, err
+}
+
+// LineColumn returns 1-based line and column (in runes) of offset in json.
+func LineColumn(json string, offset int) (line, column int) {
+	var p linePos
+	p.advance(json, min(offset, len(json)))
+	return p.line, p.column
+}
+
+// linePos is line and column of offset in text. It is moved forward incrementally,
+// so positions of many errors recovered by DecodeAll are not counted from the start.
+type linePos struct {
+	off    int
+	line   int
+	column int
+}
+
+// advance moves p to offset off of text.
+func (p *linePos) advance(text string, off int) {
+	if p.line == 0 || off < p.off {
+		*p = linePos{line: 1, column: 1}
+	}
+	seg := text[p.off:off]
+	if n := strings.Count(seg, "\n"); n > 0 {
+		p.line += n
+		p.column = 1
+		seg = seg[strings.LastIndexByte(seg, '\n')+1:]
+	}
+	p.column += utf8.RuneCountInString(seg)
+	p.off = off
+}
+
+// pushIndex appends array index to the current pointer, it returns the previous length
+// of the pointer to restore it after the element.
+func (s *decodeState) pushIndex(i int) int {
+	x := s.extra
+	n := len(x.pointer)
+	x.pointer = strconv.AppendInt(append(x.pointer, '/'), int64(i), 10)
+	return n
+}
+
+// pushKey appends escaped object key to the current pointer, it returns the previous length
+// of the pointer to restore it after the member.
+func (s *decodeState) pushKey(key string) int {
+	x := s.extra
+	n := len(x.pointer)
+	x.pointer = append(x.pointer, '/')
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '~':
+			x.pointer = append(x.pointer, '~', '0')
+		case '/':
+			x.pointer = append(x.pointer, '~', '1')
+		default:
+			x.pointer = append(x.pointer, key[i])
+		}
+	}
+	return n
+}
@@ -0,0 +1,41 @@
+package sjson_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("positions", func() {
+	in := "{\n  \"port\": 80,\n  \"hosts\": [\"a\", {\"a/b~\": null}],\n  \"x\\ny\": \"\\u0041\"\n}"
+	expected := map[string]sjson.Span{
+		"":                {0, 70},
+		"/port":           {12, 14},
+		"/hosts":          {27, 48},
+		"/hosts/0":        {28, 31},
+		"/hosts/1":        {33, 47},
+		"/hosts/1/a~1b~0": {42, 46},
+		"/x\ny":           {60, 68},
+	}
+	It("should return spans of all values", func() {
+		_, positions, err := sjson.DecodeWithPositions(in)
+		Expect(err).To(Succeed())
+		Expect(positions).To(Equal(expected))
+		Expect(in[positions["/hosts/1"].Start:positions["/hosts/1"].End]).To(Equal(`{"a/b~": null}`))
+
+		_, positions, err = sjson.NewDecoder(sjson.DecodeOptions{}).DecodeWithPositions(in)
+		Expect(err).To(Succeed())
+		Expect(positions).To(Equal(expected))
+	})
+	It("should convert offset to line and column", func() {
+		line, column := sjson.LineColumn(in, expected["/hosts/1"].Start)
+		Expect([]int{line, column}).To(Equal([]int{3, 18}))
+		line, column = sjson.LineColumn(in, 0)
+		Expect([]int{line, column}).To(Equal([]int{1, 1}))
+	})
+	It("should not track positions by default", func() {
+		res, err := sjson.Decode(in)
+		Expect(err).To(Succeed())
+		Expect(res).To(HaveKey("port"))
+	})
+})
@@ -0,0 +1,120 @@
+package sjson
+
+import "strconv"
+
+// DecodeAll parses JSON Text like Decode, but does not stop at the first error inside
+// of arrays and objects. After such error it skips input to the next ',' or closing
+// bracket of the container and continues, so all problems of the input are reported.
+// The returned value is the best-effort tree, failed elements are nil or partially
+// decoded values, elements skipped while resynchronizing are missing.
+func DecodeAll(json string) (interface{}, []error) {
+	state := decodeState{cur: json, opts: &defaultOptions, extra: &decodeExtra{recover: true}}
+	return state.decodeAll()
+}
+
+// DecodeAll parses JSON Text in the same way as DecodeAll function does.
+func (d *Decoder) DecodeAll(json string) (interface{}, []error) {
+	state := d.acquire(json)
+	state.extra = &decodeExtra{recover: true}
+	val, errs := state.decodeAll()
+	d.release(&state)
+	return val, errs
+}
+
+func (s *decodeState) decodeAll() (interface{}, []error) {
+	val, err := s.decode()
+	if err != nil {
+		s.extra.errs = append(s.extra.errs, err)
+	}
+	return val, s.extra.errs
+}
+
+// recoverError makes DecodeAll continue after the error in container ending with closer.
+// The error is recorded and input is skipped up to the next ',' or closer of the container.
+// It returns false if decoding can not continue.
+func (s *decodeState) recoverError(closer byte) bool {
+	if _, ok := s.err.(*LimitError); ok || s.extra == nil || !s.extra.recover {
+		return false
+	}
+	off := s.off
+	if e, ok := s.err.(*SyntaxError); ok && (e.Kind == InvalidEscape || e.Kind == InvalidCharacter) {
+		// error is inside of string, skip to its end
+		if off = skipToQuote(s.cur, off); off < 0 {
+			return false
+		}
+		off++
+	}
+	for depth := 0; off < len(s.cur); off++ {
+		switch c := s.cur[off]; c {
+		case '"':
+			if off = skipToQuote(s.cur, off+1); off < 0 {
+				return false
+			}
+		case '[', '{':
+			depth++
+		case ']', '}':
+			if depth > 0 {
+				depth--
+			} else if c != closer {
+				return false
+			} else {
+				s.resume(off)
+				return true
+			}
+		case ',':
+			if depth == 0 {
+				s.resume(off)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enterElement is called with extra state before the value of array element i, or of
+// object member key if i < 0. It returns the mark of recovered errors and the length of
+// JSON Pointer, which are passed to leaveElement after the value.
+func (s *decodeState) enterElement(i int, key string) (mark, ptr int) {
+	if s.extra.positions != nil {
+		if i >= 0 {
+			ptr = s.pushIndex(i)
+		} else {
+			ptr = s.pushKey(key)
+		}
+	}
+	return len(s.extra.errs), ptr
+}
+
+// leaveElement restores JSON Pointer and adds reference token of the element to errors
+// recovered inside of it. Path of the current error is added by the caller.
+func (s *decodeState) leaveElement(mark, ptr int, i int, key string) {
+	x := s.extra
+	if x.positions != nil {
+		x.pointer = x.pointer[:ptr]
+	}
+	if s.err == nil && len(x.errs) > mark {
+		if i >= 0 {
+			key = strconv.Itoa(i)
+		}
+		s.addPath(mark, key)
+	}
+}
+
+func (s *decodeState) resume(off int) {
+	s.extra.errs = append(s.extra.errs, s.err)
+	s.err = nil
+	s.off = off
+}
+
+// skipToQuote returns position of the close quote of string starting before off, or -1.
+func skipToQuote(str string, off int) int {
+	for ; off < len(str); off++ {
+		switch str[off] {
+		case '\\':
+			off++
+		case '"':
+			return off
+		}
+	}
+	return -1
+}
@@ -0,0 +1,53 @@
+package sjson_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("DecodeAll", func() {
+	pointers := func(errs []error) []string {
+		var res []string
+		for _, err := range errs {
+			res = append(res, err.(*sjson.SyntaxError).Pointer())
+		}
+		return res
+	}
+	It("should decode correct input without errors", func() {
+		res, errs := sjson.DecodeAll(`{"a":[1,2],"b":null}`)
+		Expect(errs).To(BeEmpty())
+		Expect(res).To(Equal(map[string]interface{}{"a": []interface{}{1.0, 2.0}, "b": nil}))
+	})
+	It("should report all errors and return best-effort tree", func() {
+		in := `[{"a":tru,"b":2,"c":"x\qy"},[1,-,3],{"d" 4,"e":5},[1 2,3],"ok"]`
+		res, errs := sjson.DecodeAll(in)
+		Expect(pointers(errs)).To(Equal([]string{"/0/a", "/0/c", "/1/1", "/2", "/3"}))
+		Expect(errors.Is(errs[0], sjson.UnexpectedToken)).To(BeTrue())
+		Expect(errors.Is(errs[1], sjson.InvalidEscape)).To(BeTrue())
+		Expect(errors.Is(errs[2], sjson.BadNumber)).To(BeTrue())
+		Expect(res).To(Equal([]interface{}{
+			map[string]interface{}{"a": nil, "b": 2.0, "c": ""},
+			[]interface{}{1.0, 0.0, 3.0},
+			map[string]interface{}{"e": 5.0},
+			[]interface{}{1.0, 3.0},
+			"ok",
+		}))
+	})
+	It("should recover from unescaped control characters and trailing commas", func() {
+		res, errs := sjson.NewDecoder(sjson.DecodeOptions{Strict: true}).DecodeAll("{\"a\":\"x\ty\",\"b\":[1,],}")
+		Expect(pointers(errs)).To(Equal([]string{"/a", "/b/1", ""}))
+		Expect(res).To(Equal(map[string]interface{}{"a": "", "b": []interface{}{1.0, nil}}))
+	})
+	It("should stop at unrecoverable error", func() {
+		res, errs := sjson.DecodeAll(`[1,x,[2,"abc`)
+		Expect(pointers(errs)).To(Equal([]string{"/1", "/2/1"}))
+		Expect(errors.Is(errs[1], sjson.UnexpectedEOF)).To(BeTrue())
+		Expect(res).To(Equal([]interface{}{1.0, nil}))
+
+		_, errs = sjson.DecodeAll(`{"a":1]`)
+		Expect(errs).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,88 @@
+package sjson
+
+import "strconv"
+
+// scratchLimit is the capacity of scratch buffers above which Decoder.Reset drops them,
+// so a pooled Decoder does not keep memory of a single huge document.
+const scratchLimit = 1 << 16
+
+// scratch holds buffers which Decoder reuses between calls.
+type scratch struct {
+	stack []interface{} // elements of arrays being decoded
+	buf   []byte        // unescaped string
+
+	members []Member         // members of objects being built, see Builder
+	index   []map[string]int // indexes of large objects being built by nesting depth
+}
+
+func (sc *scratch) reset() {
+	if cap(sc.stack) > scratchLimit {
+		sc.stack = nil
+	}
+	if cap(sc.members) > scratchLimit {
+		sc.members = nil
+	}
+	sc.index = nil
+	if cap(sc.buf) > scratchLimit {
+		sc.buf = nil
+	}
+}
+
+// decodeSliceScratch is decodeSlice for decoders with scratch buffers. Elements are
+// collected on the stack and copied into slice of exact size when the array is complete.
+func (s *decodeState) decodeSliceScratch() []interface{} {
+	s.skipSpaces()
+
+	if len(s.cur) > s.off && s.cur[s.off] == ']' {
+		s.off++
+		return []interface{}{}
+	}
+
+	sc := s.scratch
+	base := len(sc.stack)
+	path := s.path
+	if s.shapes != nil {
+		s.path = arrayPath(path)
+	}
+	for {
+		var mark, ptr int
+		if s.extra != nil {
+			mark, ptr = s.enterElement(len(sc.stack)-base, "")
+		}
+		sc.stack = append(sc.stack, s.decodeValue())
+		if s.extra != nil {
+			s.leaveElement(mark, ptr, len(sc.stack)-base-1, "")
+		}
+		if s.err != nil {
+			s.addPath(mark, strconv.Itoa(len(sc.stack)-base-1))
+			if !s.recoverError(']') {
+				break
+			}
+		}
+		if s.opts.MaxArrayLen > 0 && len(sc.stack)-base > s.opts.MaxArrayLen {
+			s.limitError(ArrayLimit, s.opts.MaxArrayLen, s.off)
+			break
+		}
+		s.skipSpaces()
+		if len(s.cur) > s.off && s.cur[s.off] == ',' {
+			s.off++
+			continue
+		}
+		if !s.nextElement() {
+			break
+		}
+	}
+	s.path = path
+
+	elems := sc.stack[base:]
+	var arr []interface{}
+	if s.arena != nil {
+		arr = s.arena.makeSlice(elems)
+	} else {
+		arr = make([]interface{}, len(elems))
+		copy(arr, elems)
+	}
+	clear(elems)
+	sc.stack = sc.stack[:base]
+	return arr
+}
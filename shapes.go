@@ -0,0 +1,66 @@
+package sjson
+
+import (
+	"sync/atomic"
+)
+
+const (
+	shapeTableBits = 12
+	shapeTableSize = 1 << shapeTableBits
+
+	// maxShapeHint limits presizing, so one huge container does not make all following allocations big
+	maxShapeHint = 1 << 16
+
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// shapeTable records typical sizes of objects by hash of its nesting path.
+// Paths with the same hash share one slot, which only makes hints less precise.
+// Slots are accessed atomically, so one table may be used by concurrent decoders.
+type shapeTable struct {
+	sizes [shapeTableSize]uint32 // moving average of size + 1, 0 if nothing was observed
+}
+
+func (t *shapeTable) slot(path uint64) *uint32 {
+	return &t.sizes[(path^path>>32)&(shapeTableSize-1)]
+}
+
+// hint returns expected size of container at path or def if unknown.
+func (t *shapeTable) hint(path uint64, def int) int {
+	if v := atomic.LoadUint32(t.slot(path)); v != 0 {
+		return int(v - 1)
+	}
+	return def
+}
+
+// observe records size of container at path.
+func (t *shapeTable) observe(path uint64, n int) {
+	if n > maxShapeHint {
+		n = maxShapeHint
+	}
+	p := t.slot(path)
+	v := uint32(n) + 1
+	if old := atomic.LoadUint32(p); old != 0 {
+		// exponential moving average, rounded up
+		v = (3*old + v + 3) / 4
+	}
+	atomic.StoreUint32(p, v)
+}
+
+// rootPath is the path of the top level value.
+const rootPath uint64 = fnvOffset64
+
+// memberPath returns path of object member with key (FNV-1a hash).
+func memberPath(path uint64, key string) uint64 {
+	h := (path ^ '{') * fnvPrime64
+	for i := 0; i < len(key); i++ {
+		h = (h ^ uint64(key[i])) * fnvPrime64
+	}
+	return h
+}
+
+// arrayPath returns path of array elements.
+func arrayPath(path uint64) uint64 {
+	return (path ^ '[') * fnvPrime64
+}
@@ -0,0 +1,35 @@
+package sjson
+
+import (
+	"math/bits"
+)
+
+const (
+	swarLo = 0x0101010101010101
+	swarHi = 0x8080808080808080
+)
+
+// findStringSpecialGeneric is a pure Go (SWAR) implementation of findStringSpecial.
+// It checks 8 bytes at once, see https://graphics.stanford.edu/~seander/bithacks.html#ZeroInWord
+func findStringSpecialGeneric(s string) int {
+	i := 0
+	for ; len(s)-i >= 8; i += 8 {
+		x := uint64(s[i]) | uint64(s[i+1])<<8 | uint64(s[i+2])<<16 | uint64(s[i+3])<<24 |
+			uint64(s[i+4])<<32 | uint64(s[i+5])<<40 | uint64(s[i+6])<<48 | uint64(s[i+7])<<56
+		q := x ^ ('"' * swarLo)
+		b := x ^ ('\\' * swarLo)
+		// high bit is set for zero bytes of q and b and for bytes of x less than 0x20,
+		// bytes above the first match may be false positives
+		mask := ((q - swarLo) &^ q) | ((b - swarLo) &^ b) | ((x - 0x20*swarLo) &^ x)
+		mask &= swarHi
+		if mask != 0 {
+			return i + bits.TrailingZeros64(mask)/8
+		}
+	}
+	for ; i < len(s); i++ {
+		if c := s[i]; c == '"' || c == '\\' || c < '\x20' {
+			return i
+		}
+	}
+	return -1
+}
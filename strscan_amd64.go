@@ -0,0 +1,13 @@
+//go:build !purego
+
+package sjson
+
+// indexStringSpecial is implemented with SSE2 instructions, which are available on every amd64 CPU.
+//
+//go:noescape
+func indexStringSpecial(s string) int
+
+// findStringSpecial returns position of the first quote, backslash or control character in s, or -1.
+func findStringSpecial(s string) int {
+	return indexStringSpecial(s)
+}
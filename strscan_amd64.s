@@ -0,0 +1,71 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func indexStringSpecial(s string) int
+// Finds the first '"', '\\' or byte <= 0x1F. Control bytes x are found as min(x, 0x1F) == x.
+TEXT ·indexStringSpecial(SB), NOSPLIT, $0-24
+	MOVQ s_base+0(FP), SI
+	MOVQ s_len+8(FP), BX
+	MOVQ SI, DI
+
+	MOVL   $0x22222222, AX
+	MOVQ   AX, X1
+	PSHUFD $0, X1, X1
+	MOVL   $0x5C5C5C5C, AX
+	MOVQ   AX, X2
+	PSHUFD $0, X2, X2
+	MOVL   $0x1F1F1F1F, AX
+	MOVQ   AX, X3
+	PSHUFD $0, X3, X3
+
+loop:
+	CMPQ     BX, $16
+	JB       tail
+	MOVOU    (SI), X0
+	MOVO     X0, X4
+	PCMPEQB  X1, X4
+	MOVO     X0, X5
+	PCMPEQB  X2, X5
+	MOVO     X0, X6
+	PMINUB   X3, X6
+	PCMPEQB  X0, X6
+	POR      X5, X4
+	POR      X6, X4
+	PMOVMSKB X4, AX
+	TESTL    AX, AX
+	JNZ      found
+	ADDQ     $16, SI
+	SUBQ     $16, BX
+	JMP      loop
+
+tail:
+	TESTQ   BX, BX
+	JZ      notfound
+	MOVBLZX (SI), AX
+	CMPB    AL, $0x22
+	JEQ     tailfound
+	CMPB    AL, $0x5C
+	JEQ     tailfound
+	CMPB    AL, $0x1F
+	JLS     tailfound
+	INCQ    SI
+	DECQ    BX
+	JMP     tail
+
+tailfound:
+	SUBQ DI, SI
+	MOVQ SI, ret+16(FP)
+	RET
+
+found:
+	// AX is a mask of matched bytes in the block at SI
+	BSFL AX, AX
+	SUBQ DI, SI
+	ADDQ SI, AX
+	MOVQ AX, ret+16(FP)
+	RET
+
+notfound:
+	MOVQ $-1, ret+16(FP)
+	RET
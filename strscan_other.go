@@ -0,0 +1,8 @@
+//go:build !amd64 || purego
+
+package sjson
+
+// findStringSpecial returns position of the first quote, backslash or control character in s, or -1.
+func findStringSpecial(s string) int {
+	return findStringSpecialGeneric(s)
+}
@@ -0,0 +1,46 @@
+package sjson_test
+
+import (
+	"math/rand"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+func findStringSpecialNaive(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' || s[i] < 0x20 {
+			return i
+		}
+	}
+	return -1
+}
+
+var _ = Describe("string scanning", func() {
+	It("should find quote, backslash and control characters", func() {
+		rnd := rand.New(rand.NewSource(1))
+		specials := []byte{'"', '\\', 0x00, 0x1F, '\n'}
+		for n := 0; n < 100; n++ {
+			buf := []byte(strings.Repeat("a\x20\x7F\xFFü", 20)[:n])
+			Expect(sjson.FindStringSpecial(string(buf))).To(Equal(-1))
+			Expect(sjson.FindStringSpecialGeneric(string(buf))).To(Equal(-1))
+			for i := 0; i < n; i++ {
+				c := buf[i]
+				buf[i] = specials[rnd.Intn(len(specials))]
+				if i+1 < n && rnd.Intn(2) == 0 {
+					// matches after the first one should not matter
+					buf[i+1] = specials[rnd.Intn(len(specials))]
+				}
+				expect := findStringSpecialNaive(string(buf))
+				Expect(sjson.FindStringSpecial(string(buf))).To(Equal(expect), "%q", buf)
+				Expect(sjson.FindStringSpecialGeneric(string(buf))).To(Equal(expect), "%q", buf)
+				buf[i] = c
+				if i+1 < n {
+					buf[i+1] = strings.Repeat("a\x20\x7F\xFFü", 20)[i+1]
+				}
+			}
+		}
+	})
+})
@@ -0,0 +1,258 @@
+package sjson
+
+import (
+	"io"
+	"strconv"
+)
+
+// TokenKind is a kind of token returned by Tokenizer.
+type TokenKind int
+
+const (
+	TokenObjectStart TokenKind = iota + 1 // '{'
+	TokenObjectEnd                        // '}'
+	TokenArrayStart                       // '['
+	TokenArrayEnd                         // ']'
+	TokenKey                              // object key, the following ':' is consumed but not covered by Offset/End
+	TokenString                           // string value
+	TokenNumber                           // number value
+	TokenBool                             // true or false
+	TokenNull                             // null
+)
+
+var tokenKindNames = [...]string{"Invalid", "ObjectStart", "ObjectEnd", "ArrayStart", "ArrayEnd", "Key", "String", "Number", "Bool", "Null"}
+
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(tokenKindNames) {
+		return "TokenKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return tokenKindNames[k]
+}
+
+// A Token is a lexical element of JSON Text.
+type Token struct {
+	Kind   TokenKind
+	Str    string  // value of TokenKey and TokenString tokens
+	Num    float64 // value of TokenNumber token
+	Bool   bool    // value of TokenBool token
+	Offset int     // offset of the first byte of the token
+	End    int     // offset after the last byte of the token
+}
+
+type tokExpect int
+
+const (
+	expValue tokExpect = iota
+	expValueOrEnd
+	expKey
+	expKeyOrEnd
+	expCommaOrEnd
+	expDone
+)
+
+// Tokenizer is a pull lexer of JSON Text. It allows to process huge documents
+// element by element without decoding them in memory.
+// Strings without escapes are returned as slices of the source string.
+type Tokenizer struct {
+	state  decodeState
+	stack  []byte // '{' or '[' of open containers
+	expect tokExpect
+}
+
+// NewTokenizer returns tokenizer of JSON Text. As Decode, it accepts missing and
+// trailing commas in objects.
+func NewTokenizer(json string) *Tokenizer {
+	return &Tokenizer{state: decodeState{cur: json, opts: &defaultOptions}}
+}
+
+// Next returns the next token. It returns io.EOF after the end of JSON Text.
+func (t *Tokenizer) Next() (Token, error) {
+	s := &t.state
+	if s.err != nil || !t.skipSeparator() {
+		return Token{}, s.err
+	}
+
+	tok := Token{Offset: s.off}
+	switch t.expect {
+	case expDone:
+		if len(s.cur) > s.off {
+			s.error(TrailingData, "incorrect syntax - unexpected data after value")
+			return Token{}, s.err
+		}
+		return Token{}, io.EOF
+	case expCommaOrEnd:
+		t.closeContainer(&tok)
+	case expKey, expKeyOrEnd:
+		if len(s.cur) > s.off && s.cur[s.off] == '}' && t.expect == expKeyOrEnd {
+			t.closeContainer(&tok)
+		} else if len(s.cur) > s.off && s.cur[s.off] == '"' {
+			s.off++
+			tok.Kind = TokenKey
+			tok.Str = s.decodeString()
+			if s.err != nil {
+				return Token{}, s.err
+			}
+			tok.End = s.off
+			s.skipSpaces()
+			if len(s.cur) > s.off && s.cur[s.off] == ':' {
+				s.off++
+			} else {
+				s.error(UnexpectedToken, "incorrect syntax - expect ':' after object key")
+			}
+			t.expect = expValue
+		} else {
+			s.error(UnexpectedToken, "incorrect syntax - expect object key or incomplete object")
+		}
+	case expValueOrEnd:
+		if len(s.cur) > s.off && s.cur[s.off] == ']' {
+			t.closeContainer(&tok)
+			break
+		}
+		fallthrough
+	default:
+		t.readValue(&tok)
+	}
+
+	if s.err != nil {
+		return Token{}, s.err
+	}
+	return tok, nil
+}
+
+// More reports whether there is another element in the current array or object.
+func (t *Tokenizer) More() bool {
+	s := &t.state
+	if s.err != nil || !t.skipSeparator() || t.expect == expDone {
+		return false
+	}
+	return len(s.cur) > s.off && s.cur[s.off] != ']' && s.cur[s.off] != '}'
+}
+
+// DecodeValue decodes the next value (which may be an array or an object) in the same
+// way as Decode does. It should be called only where value is expected, i.e. at the
+// start, after TokenKey or inside an array.
+func (t *Tokenizer) DecodeValue() (interface{}, error) {
+	s := &t.state
+	if s.err != nil || !t.skipSeparator() {
+		return nil, s.err
+	}
+	if t.expect != expValue && t.expect != expValueOrEnd {
+		s.error(UnexpectedToken, "incorrect syntax - expect value")
+		return nil, s.err
+	}
+	val := s.decodeValue()
+	if s.err != nil {
+		return nil, s.err
+	}
+	t.afterValue()
+	return val, nil
+}
+
+// skipSeparator skips spaces and comma between container elements.
+func (t *Tokenizer) skipSeparator() bool {
+	s := &t.state
+	s.skipSpaces()
+	if t.expect != expCommaOrEnd {
+		return true
+	}
+	if len(s.cur) <= s.off {
+		if t.stack[len(t.stack)-1] == '[' {
+			s.error(UnexpectedToken, "incorrect syntax - incomplete array")
+		} else {
+			s.error(UnexpectedToken, "incorrect syntax - object")
+		}
+		return false
+	}
+	comma := s.cur[s.off] == ','
+	if comma {
+		s.off++
+		s.skipSpaces()
+	}
+	switch {
+	case t.stack[len(t.stack)-1] == '[':
+		if comma {
+			t.expect = expValue
+		}
+	case len(s.cur) > s.off && (s.cur[s.off] == '"' || comma && s.cur[s.off] == '}'):
+		if !s.checkObjectComma(s.cur[s.off], 1, comma) {
+			return false
+		}
+		t.expect = expKeyOrEnd
+	case comma:
+		t.expect = expKey
+	}
+	return true
+}
+
+func (t *Tokenizer) closeContainer(tok *Token) {
+	s := &t.state
+	top := t.stack[len(t.stack)-1]
+	switch {
+	case top == '[' && len(s.cur) > s.off && s.cur[s.off] == ']':
+		tok.Kind = TokenArrayEnd
+	case top == '{' && len(s.cur) > s.off && s.cur[s.off] == '}':
+		tok.Kind = TokenObjectEnd
+	case top == '[':
+		s.error(UnexpectedToken, "incorrect syntax - incomplete array")
+		return
+	default:
+		s.error(UnexpectedToken, "incorrect syntax - expect object key or incomplete object")
+		return
+	}
+	s.off++
+	tok.End = s.off
+	t.stack = t.stack[:len(t.stack)-1]
+	t.afterValue()
+}
+
+func (t *Tokenizer) readValue(tok *Token) {
+	s := &t.state
+	if len(s.cur) <= s.off {
+		s.error(UnexpectedToken, "incorrect syntax - expect value")
+		return
+	}
+	switch s.cur[s.off] {
+	case '{':
+		s.off++
+		tok.Kind = TokenObjectStart
+		t.stack = append(t.stack, '{')
+		t.expect = expKeyOrEnd
+		tok.End = s.off
+		return
+	case '[':
+		s.off++
+		tok.Kind = TokenArrayStart
+		t.stack = append(t.stack, '[')
+		t.expect = expValueOrEnd
+		tok.End = s.off
+		return
+	case '"':
+		s.off++
+		tok.Kind = TokenString
+		tok.Str = s.decodeString()
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		tok.Kind = TokenNumber
+		tok.Num = s.decodeNumber()
+	default:
+		val := s.decodeValue()
+		if s.err != nil {
+			return
+		}
+		if b, ok := val.(bool); ok {
+			tok.Kind = TokenBool
+			tok.Bool = b
+		} else {
+			tok.Kind = TokenNull
+		}
+	}
+	tok.End = s.off
+	t.afterValue()
+}
+
+func (t *Tokenizer) afterValue() {
+	if len(t.stack) == 0 {
+		t.expect = expDone
+	} else {
+		t.expect = expCommaOrEnd
+	}
+}
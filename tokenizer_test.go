@@ -0,0 +1,98 @@
+package sjson_test
+
+import (
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+var _ = Describe("tokenizer", func() {
+	tokens := func(json string) ([]sjson.Token, error) {
+		t := sjson.NewTokenizer(json)
+		var res []sjson.Token
+		for {
+			tok, err := t.Next()
+			if err == io.EOF {
+				return res, nil
+			}
+			if err != nil {
+				return res, err
+			}
+			res = append(res, tok)
+		}
+	}
+
+	It("should return tokens with offsets", func() {
+		res, err := tokens(` {"a" : [1, "x\"", true, null], "b":{}} `)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal([]sjson.Token{
+			{Kind: sjson.TokenObjectStart, Offset: 1, End: 2},
+			{Kind: sjson.TokenKey, Str: "a", Offset: 2, End: 5},
+			{Kind: sjson.TokenArrayStart, Offset: 8, End: 9},
+			{Kind: sjson.TokenNumber, Num: 1, Offset: 9, End: 10},
+			{Kind: sjson.TokenString, Str: `x"`, Offset: 12, End: 17},
+			{Kind: sjson.TokenBool, Bool: true, Offset: 19, End: 23},
+			{Kind: sjson.TokenNull, Offset: 25, End: 29},
+			{Kind: sjson.TokenArrayEnd, Offset: 29, End: 30},
+			{Kind: sjson.TokenKey, Str: "b", Offset: 32, End: 35},
+			{Kind: sjson.TokenObjectStart, Offset: 36, End: 37},
+			{Kind: sjson.TokenObjectEnd, Offset: 37, End: 38},
+			{Kind: sjson.TokenObjectEnd, Offset: 38, End: 39},
+		}))
+	})
+	It("should return scalar top level value", func() {
+		res, err := tokens(`"abc"`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal([]sjson.Token{{Kind: sjson.TokenString, Str: "abc", Offset: 0, End: 5}}))
+	})
+	It("should accept missing and trailing commas in objects as Decode", func() {
+		res, err := tokens(`{"a":1 "b":2,}`)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal([]sjson.Token{
+			{Kind: sjson.TokenObjectStart, Offset: 0, End: 1},
+			{Kind: sjson.TokenKey, Str: "a", Offset: 1, End: 4},
+			{Kind: sjson.TokenNumber, Num: 1, Offset: 5, End: 6},
+			{Kind: sjson.TokenKey, Str: "b", Offset: 7, End: 10},
+			{Kind: sjson.TokenNumber, Num: 2, Offset: 11, End: 12},
+			{Kind: sjson.TokenObjectEnd, Offset: 13, End: 14},
+		}))
+	})
+	It("should detect syntax errors", func() {
+		_, err := tokens(`[1 2]`)
+		ExpectSyntaxErr(`incomplete array`, 3)(err)
+		_, err = tokens(`{"a":1,,}`)
+		ExpectSyntaxErr(`expect object key`, 7)(err)
+		_, err = tokens(`{"a":1 2}`)
+		ExpectSyntaxErr(`expect object key`, 7)(err)
+		_, err = tokens(`{"a" 1}`)
+		ExpectSyntaxErr(`expect ':' after object key`, 5)(err)
+		_, err = tokens(`[1]]`)
+		ExpectSyntaxErr(`unexpected data after value`, 3)(err)
+		_, err = tokens(`[1,`)
+		ExpectSyntaxErr(`expect value`, 3)(err)
+	})
+	It("should decode array element by element", func() {
+		t := sjson.NewTokenizer(`[{"n":1}, {"n":2}, 3]`)
+		tok, err := t.Next()
+		Expect(err).To(Succeed())
+		Expect(tok.Kind).To(Equal(sjson.TokenArrayStart))
+		var elems []interface{}
+		for t.More() {
+			v, err := t.DecodeValue()
+			Expect(err).To(Succeed())
+			elems = append(elems, v)
+		}
+		Expect(elems).To(Equal([]interface{}{
+			map[string]interface{}{"n": 1.0},
+			map[string]interface{}{"n": 2.0},
+			3.0,
+		}))
+		tok, err = t.Next()
+		Expect(err).To(Succeed())
+		Expect(tok.Kind).To(Equal(sjson.TokenArrayEnd))
+		_, err = t.Next()
+		Expect(err).To(Equal(io.EOF))
+	})
+})
@@ -0,0 +1,282 @@
+package sjson
+
+import (
+	"errors"
+)
+
+// WalkAction is returned by Handler methods to control Walk.
+type WalkAction int
+
+const (
+	// WalkContinue continues the walk.
+	WalkContinue WalkAction = iota
+	// WalkSkip skips the current subtree, when returned from OnObjectStart or OnArrayStart
+	// (the matching end method is not called), or the value of the key, when returned from OnKey.
+	// For other methods it is the same as WalkContinue.
+	WalkSkip
+	// WalkAbort stops the walk, Walk returns ErrWalkAborted.
+	WalkAbort
+)
+
+// ErrWalkAborted is returned by Walk if a handler aborted the walk.
+var ErrWalkAborted = errors.New("walk aborted")
+
+// Handler receives events from Walk.
+type Handler interface {
+	OnObjectStart() WalkAction
+	OnObjectEnd() WalkAction
+	OnKey(key string) WalkAction
+	OnArrayStart() WalkAction
+	OnArrayEnd() WalkAction
+	OnString(s string) WalkAction
+	OnNumber(f float64) WalkAction
+	OnBool(b bool) WalkAction
+	OnNull() WalkAction
+}
+
+// NopHandler implements Handler with methods which do nothing. It may be embedded
+// to implement only needed methods.
+type NopHandler struct{}
+
+func (NopHandler) OnObjectStart() WalkAction     { return WalkContinue }
+func (NopHandler) OnObjectEnd() WalkAction       { return WalkContinue }
+func (NopHandler) OnKey(key string) WalkAction   { return WalkContinue }
+func (NopHandler) OnArrayStart() WalkAction      { return WalkContinue }
+func (NopHandler) OnArrayEnd() WalkAction        { return WalkContinue }
+func (NopHandler) OnString(s string) WalkAction  { return WalkContinue }
+func (NopHandler) OnNumber(f float64) WalkAction { return WalkContinue }
+func (NopHandler) OnBool(b bool) WalkAction      { return WalkContinue }
+func (NopHandler) OnNull() WalkAction            { return WalkContinue }
+
+// Walk parses JSON Text and calls handler methods for every element of it without
+// building values in memory. Skipped subtrees are checked only for balance of brackets.
+// As Decode, it accepts missing and trailing commas in objects.
+func Walk(json string, h Handler) error {
+	w := walkState{decodeState: decodeState{cur: json, opts: &defaultOptions}, h: h}
+	w.walkValue()
+	return w.err
+}
+
+type walkState struct {
+	decodeState
+	h Handler
+}
+
+// act handles the action returned by handler, returns true if walk should continue.
+func (w *walkState) act(a WalkAction) bool {
+	if a == WalkAbort {
+		w.err = ErrWalkAborted
+		return false
+	}
+	return true
+}
+
+func (w *walkState) walkValue() {
+	w.skipSpaces()
+	if len(w.cur) <= w.off {
+		w.error(UnexpectedToken, "incorrect syntax - expect value")
+		return
+	}
+	switch w.cur[w.off] {
+	case '"':
+		w.off++
+		str := w.decodeString()
+		if w.err == nil {
+			w.act(w.h.OnString(str))
+		}
+	case '{', '[':
+		if !w.enter() {
+			return
+		}
+		if w.cur[w.off] == '{' {
+			w.walkObject()
+		} else {
+			w.walkArray()
+		}
+		w.leave()
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		f := w.decodeNumber()
+		if w.err == nil {
+			w.act(w.h.OnNumber(f))
+		}
+	default:
+		val := w.decodeValue()
+		if w.err != nil {
+			return
+		}
+		if b, ok := val.(bool); ok {
+			w.act(w.h.OnBool(b))
+		} else {
+			w.act(w.h.OnNull())
+		}
+	}
+}
+
+func (w *walkState) walkObject() {
+	switch w.h.OnObjectStart() {
+	case WalkAbort:
+		w.act(WalkAbort)
+		return
+	case WalkSkip:
+		w.skipValue()
+		return
+	}
+	w.off++
+
+	var n int // number of members
+	var comma bool
+	for {
+		w.skipSpaces()
+		if len(w.cur) <= w.off {
+			w.error(UnexpectedToken, "incorrect syntax - object")
+			return
+		}
+		switch w.cur[w.off] {
+		case '}':
+			if w.checkObjectComma('}', n, comma) {
+				w.off++
+				w.act(w.h.OnObjectEnd())
+			}
+			return
+		case '"':
+			if !w.checkObjectComma('"', n, comma) {
+				return
+			}
+		default:
+			w.error(UnexpectedToken, "incorrect syntax - expect object key or incomplete object")
+			return
+		}
+		w.off++
+		key := w.decodeString()
+		if w.err != nil {
+			return
+		}
+		w.skipSpaces()
+		if len(w.cur) > w.off && w.cur[w.off] == ':' {
+			w.off++
+		} else {
+			w.error(UnexpectedToken, "incorrect syntax - expect ':' after object key")
+			return
+		}
+
+		switch w.h.OnKey(key) {
+		case WalkAbort:
+			w.act(WalkAbort)
+		case WalkSkip:
+			w.skipValue()
+		default:
+			w.walkValue()
+		}
+		if w.err != nil {
+			return
+		}
+		n++
+
+		w.skipSpaces()
+		comma = len(w.cur) > w.off && w.cur[w.off] == ','
+		if comma {
+			w.off++
+		}
+	}
+}
+
+func (w *walkState) walkArray() {
+	switch w.h.OnArrayStart() {
+	case WalkAbort:
+		w.act(WalkAbort)
+		return
+	case WalkSkip:
+		w.skipValue()
+		return
+	}
+	w.off++
+
+	w.skipSpaces()
+	if len(w.cur) > w.off && w.cur[w.off] == ']' {
+		w.off++
+		w.act(w.h.OnArrayEnd())
+		return
+	}
+
+	for {
+		w.walkValue()
+		if w.err != nil {
+			return
+		}
+
+		w.skipSpaces()
+		if len(w.cur) <= w.off {
+			w.error(UnexpectedToken, "incorrect syntax - incomplete array")
+			return
+		}
+		switch w.cur[w.off] {
+		case ',':
+			w.off++
+		case ']':
+			w.off++
+			w.act(w.h.OnArrayEnd())
+			return
+		default:
+			w.error(UnexpectedToken, "incorrect syntax - incomplete array")
+			return
+		}
+	}
+}
+
+// skipValue skips the next value. Arrays and objects are checked only for balance of brackets.
+func (s *decodeState) skipValue() {
+	s.skipSpaces()
+	if len(s.cur) <= s.off || (s.cur[s.off] != '{' && s.cur[s.off] != '[') {
+		s.decodeValue()
+		return
+	}
+
+	nest := 0
+	for len(s.cur) > s.off {
+		switch s.cur[s.off] {
+		case '"':
+			s.off++
+			s.skipString()
+			if s.err != nil {
+				return
+			}
+			continue
+		case '{', '[':
+			nest++
+		case '}', ']':
+			nest--
+			if nest == 0 {
+				s.off++
+				return
+			}
+		}
+		s.off++
+	}
+	s.error(UnexpectedToken, "incorrect syntax - incomplete value")
+}
+
+// skipString skips string without unescaping, s.off should point after the open quote.
+func (s *decodeState) skipString() {
+	for {
+		pos := findStringSpecial(s.cur[s.off:])
+		if pos < 0 {
+			s.off = len(s.cur)
+			s.error(UnexpectedToken, "incorrect syntax - expect close quote")
+			return
+		}
+		s.off += pos
+		switch {
+		case s.cur[s.off] == '"':
+			s.off++
+			return
+		case s.cur[s.off] != '\\':
+			s.error(InvalidCharacter, "incorrect syntax - expect escape sequence for control character")
+			return
+		case len(s.cur) <= s.off+1:
+			s.off = len(s.cur)
+			s.error(UnexpectedToken, "incorrect syntax - expect close quote")
+			return
+		}
+		s.off += 2
+	}
+}
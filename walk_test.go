@@ -0,0 +1,88 @@
+package sjson_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vovkasm/go-sjson"
+)
+
+type sumHandler struct {
+	sjson.NopHandler
+	skipKey string
+	abortOn string
+	sum     float64
+	count   int
+	events  []string
+}
+
+func (h *sumHandler) OnObjectStart() sjson.WalkAction {
+	h.events = append(h.events, "{")
+	return sjson.WalkContinue
+}
+func (h *sumHandler) OnObjectEnd() sjson.WalkAction {
+	h.events = append(h.events, "}")
+	return sjson.WalkContinue
+}
+func (h *sumHandler) OnArrayStart() sjson.WalkAction {
+	h.events = append(h.events, "[")
+	return sjson.WalkContinue
+}
+func (h *sumHandler) OnArrayEnd() sjson.WalkAction {
+	h.events = append(h.events, "]")
+	return sjson.WalkContinue
+}
+func (h *sumHandler) OnKey(key string) sjson.WalkAction {
+	h.events = append(h.events, key)
+	if key == h.skipKey {
+		return sjson.WalkSkip
+	}
+	return sjson.WalkContinue
+}
+func (h *sumHandler) OnString(s string) sjson.WalkAction {
+	if s == h.abortOn {
+		return sjson.WalkAbort
+	}
+	return sjson.WalkContinue
+}
+func (h *sumHandler) OnNumber(f float64) sjson.WalkAction {
+	h.sum += f
+	h.count++
+	return sjson.WalkContinue
+}
+
+var _ = Describe("walk", func() {
+	It("should walk all values", func() {
+		h := &sumHandler{}
+		err := sjson.Walk(`{"a":[1,2,{"b":3}],"c":"x","d":[true,null]}`, h)
+		Expect(err).To(Succeed())
+		Expect(h.sum).To(Equal(6.0))
+		Expect(h.count).To(Equal(3))
+		Expect(strings.Join(h.events, " ")).To(Equal(`{ a [ { b } ] c d [ ] }`))
+	})
+	It("should skip subtrees", func() {
+		h := &sumHandler{skipKey: "a"}
+		err := sjson.Walk(`{"a":[1,"]\"",{"b":3}],"c":4}`, h)
+		Expect(err).To(Succeed())
+		Expect(h.sum).To(Equal(4.0))
+		Expect(strings.Join(h.events, " ")).To(Equal(`{ a c }`))
+	})
+	It("should abort", func() {
+		h := &sumHandler{abortOn: "stop"}
+		err := sjson.Walk(`[1,"stop",2]`, h)
+		Expect(err).To(Equal(sjson.ErrWalkAborted))
+		Expect(h.sum).To(Equal(1.0))
+	})
+	It("should accept missing and trailing commas in objects as Decode", func() {
+		h := &sumHandler{}
+		Expect(sjson.Walk(`{"a":1 "b":2,}`, h)).To(Succeed())
+		Expect(h.sum).To(Equal(3.0))
+	})
+	It("should report syntax errors", func() {
+		ExpectSyntaxErr(`incomplete array`, 3)(sjson.Walk(`[1 2]`, &sumHandler{}))
+		ExpectSyntaxErr(`expect object key`, 7)(sjson.Walk(`{"a":1,,}`, &sumHandler{}))
+		ExpectSyntaxErr(`expect object key`, 7)(sjson.Walk(`{"a":1 2}`, &sumHandler{}))
+		ExpectSyntaxErr(`incomplete value`, 13)(sjson.Walk(`{"a":[1,[2]`+"  ", &sumHandler{skipKey: "a"}))
+	})
+})